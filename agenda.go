@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"time"
+)
+
+// Agenda of the active tasks across the projects, in the sections:
+//   - overdue: scheduled for the day, month or year which is over
+//   - today: of today priority, or scheduled for today
+//   - this week: of this week priority, or scheduled for the rest of the week
+//   - later: the rest
+//
+// Hooks follow the sections. Projects are picked by `agenda.projects` of
+// user.cfg, comma separated titles, every project by default.
+
+const (
+	AGENDA_OVERDUE = iota
+	AGENDA_TODAY
+	AGENDA_WEEK
+	AGENDA_LATER
+)
+
+var AGENDA_SECTIONS = [...][2]string{
+	{"overdue", "Overdue"},
+	{"today", "Today"},
+	{"week", "This week"},
+	{"later", "Later"},
+}
+
+type Agenda_Section struct {
+	Name  string
+	Title string
+	Tasks []*Task
+}
+
+type Agenda_Result struct {
+	Sections       []*Agenda_Section
+	Project_Titles map[int]string
+}
+
+// Section of the active task at the time. Weeks end on Sunday.
+func agenda_section(t *Task, now time.Time) int {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	tomorrow := today.AddDate(0, 0, 1)
+	week_end := today.AddDate(0, 0, 7-(int(today.Weekday())+6)%7)
+	var schedule *Schedule
+	if t.Schedule != nil {
+		schedule, _ = parse_schedule(*t.Schedule)
+	}
+	if schedule != nil {
+		// All day schedules are the dates of the local calendar.
+		start := schedule.Start.In(now.Location())
+		if schedule.Is_All_Day() {
+			start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, now.Location())
+		}
+		end := (&Schedule{Start: start, Precision: schedule.Precision}).End()
+		switch {
+		case schedule.Is_All_Day() && !end.After(today), !schedule.Is_All_Day() && start.Before(today):
+			return AGENDA_OVERDUE
+		case schedule.Precision >= SCHEDULE_DAY && start.Before(tomorrow):
+			return AGENDA_TODAY
+		case schedule.Precision >= SCHEDULE_DAY && start.Before(week_end) && t.Priority != TODAY_PRIORITY:
+			return AGENDA_WEEK
+		}
+	}
+	switch t.Priority {
+	case TODAY_PRIORITY:
+		return AGENDA_TODAY
+	case THIS_WEEK_PRIORITY:
+		return AGENDA_WEEK
+	default:
+		return AGENDA_LATER
+	}
+}
+
+// Ids of the projects picked by the setting, nil for every project. Unknown
+// titles are reported and skipped.
+func agenda_project_ids(setting string, project_titles map[int]string) []int {
+	if strings.TrimSpace(setting) == "" {
+		return nil
+	}
+	project_ids := []int{}
+	for _, title := range strings.Split(setting, ",") {
+		title = strings.TrimSpace(title)
+		found := false
+		for id, project_title := range project_titles {
+			if project_title == title {
+				project_ids = append(project_ids, id)
+				found = true
+			}
+		}
+		if !found {
+			bone.Log_Error("Unknown project '%s' of `agenda.projects`.", title)
+		}
+	}
+	return project_ids
+}
+
+// Show the agenda of the active tasks.
+func agenda(ctx *Command_Context) int {
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	project_titles, er := get_project_titles(tx)
+	if er != nil {
+		bone.Log_Error("During project selection, an error occured: %s", er)
+		return common.SELECT_ERROR
+	}
+	query := "SELECT * FROM task WHERE state = $1"
+	args := []any{ACTIVE}
+	project_ids := agenda_project_ids(bone.Config.Get_String("agenda", "projects", ""), project_titles)
+	if project_ids != nil {
+		placeholders := []string{}
+		for _, id := range project_ids {
+			args = append(args, id)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+		query += fmt.Sprintf(" AND project_id IN (%s)", strings.Join(placeholders, ", "))
+	}
+	tasks := []*Task{}
+	er = tx.Select(
+		&tasks,
+		query+" ORDER BY schedule IS NULL ASC, schedule ASC, completion_priority DESC, created_sec ASC, id ASC",
+		args...,
+	)
+	if er != nil {
+		bone.Log_Error("During task selection, an error occured: %s", er)
+		return common.SELECT_ERROR
+	}
+
+	result := &Agenda_Result{Project_Titles: project_titles}
+	for _, section := range AGENDA_SECTIONS {
+		result.Sections = append(result.Sections, &Agenda_Section{Name: section[0], Title: section[1]})
+	}
+	now := time.Now()
+	for _, t := range tasks {
+		section := result.Sections[agenda_section(t, now)]
+		section.Tasks = append(section.Tasks, t)
+	}
+	targets := []*Task{}
+	for _, section := range result.Sections {
+		targets = append(targets, section.Tasks...)
+	}
+	set_hooks(targets)
+	emit(result)
+	return common.OK
+}
+
+// Sections share the columns of the table, and are headed by their titles.
+func draw_agenda(w io.Writer, r *Agenda_Result, columns int, theme *Theme) {
+	rows := [][]string{}
+	// Number of the rows before the sections.
+	starts := []int{}
+	for _, section := range r.Sections {
+		starts = append(starts, len(rows))
+		for _, t := range section.Tasks {
+			schedule := ""
+			if t.Schedule != nil {
+				schedule = *t.Schedule
+			}
+			rows = append(rows, []string{
+				fmt.Sprintf("|%d|", len(rows)+1),
+				theme.Get_Priority_Mark(t),
+				fmt.Sprintf("[%s]", r.Project_Titles[t.Project_Id]),
+				schedule,
+				t.Title,
+			})
+		}
+	}
+	if len(rows) == 0 {
+		fmt.Fprint(w, "No tasks\n")
+		return
+	}
+	var b bytes.Buffer
+	write_table(&b, rows, columns)
+	lines := strings.SplitAfter(b.String(), "\n")
+
+	first := true
+	for i, section := range r.Sections {
+		if len(section.Tasks) == 0 {
+			continue
+		}
+		if !first {
+			fmt.Fprintln(w)
+		}
+		first = false
+		fmt.Fprintf(w, "%s (%d)\n", section.Title, len(section.Tasks))
+		fmt.Fprint(w, strings.Join(lines[starts[i]:starts[i]+len(section.Tasks)], ""))
+	}
+}
+
+// Tasks in the order of the hooks, with the names of their sections.
+func agenda_json_tasks(r *Agenda_Result) []*Json_Task {
+	json_tasks := []*Json_Task{}
+	for _, section := range r.Sections {
+		for _, t := range section.Tasks {
+			json_task := to_json_task(t, len(json_tasks)+1)
+			json_task.Project = r.Project_Titles[t.Project_Id]
+			json_task.Column = section.Name
+			json_tasks = append(json_tasks, json_task)
+		}
+	}
+	return json_tasks
+}
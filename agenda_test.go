@@ -0,0 +1,78 @@
+package main
+
+import (
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_agenda_section_ok(t *testing.T) {
+	// Wednesday.
+	now := time.Date(2026, 10, 21, 15, 0, 0, 0, time.UTC)
+	cases := []struct {
+		schedule string
+		priority int
+		section  int
+	}{
+		{"", SOMETIME_LATER_PRIORITY, AGENDA_LATER},
+		{"", TODAY_PRIORITY, AGENDA_TODAY},
+		{"", THIS_WEEK_PRIORITY, AGENDA_WEEK},
+		{"2026-10-20", SOMETIME_LATER_PRIORITY, AGENDA_OVERDUE},
+		{"2026-09", TODAY_PRIORITY, AGENDA_OVERDUE},
+		{"2026-10-21 09:00:00", SOMETIME_LATER_PRIORITY, AGENDA_TODAY},
+		{"2026-10-20 23:00:00", SOMETIME_LATER_PRIORITY, AGENDA_OVERDUE},
+		{"2026-10-25", SOMETIME_LATER_PRIORITY, AGENDA_WEEK},
+		{"2026-10-25", TODAY_PRIORITY, AGENDA_TODAY},
+		{"2026-10-26", SOMETIME_LATER_PRIORITY, AGENDA_LATER},
+		{"2026-10-26", THIS_WEEK_PRIORITY, AGENDA_WEEK},
+		{"2026-10", SOMETIME_LATER_PRIORITY, AGENDA_LATER},
+	}
+	for _, c := range cases {
+		task := &Task{Priority: c.priority}
+		if c.schedule != "" {
+			task.Schedule = bone.Atop(c.schedule)
+		}
+		assert.Equal(t, c.section, agenda_section(task, now), c.schedule)
+	}
+}
+
+func Test_agenda_project_ids_ok(t *testing.T) {
+	titles := map[int]string{1: "main", 2: "work", 3: "home"}
+	assert.Nil(t, agenda_project_ids(" ", titles))
+	assert.Equal(t, []int{2, 3}, agenda_project_ids("work, home,nosuch", titles))
+}
+
+func Test_agenda_ok(t *testing.T) {
+	today := time.Now().Format("2006-01-02")
+	project_id, output := with_project_output(t, "agenda", "plain")
+	tx := db.Begin()
+	other_id, er := insert_project(tx, "agenda_other")
+	assert.Nil(t, er)
+	later_id, er := insert_task(tx, "Someday", project_id)
+	assert.Nil(t, er)
+	late_id, er := insert_task(tx, "Late", other_id)
+	assert.Nil(t, er)
+	assert.Nil(t, set_task_schedule(tx, late_id, bone.Atop("2020-01-01")))
+	today_id, er := insert_task(tx, "Now", project_id)
+	assert.Nil(t, er)
+	assert.Nil(t, set_task_schedule(tx, today_id, bone.Atop(today)))
+	assert.Nil(t, tx.Commit())
+
+	assert.Equal(t, common.OK, process_input("agenda"))
+	position := func(task_id int) int {
+		for i, hook := range hooks {
+			if hook.(*Task).Id == task_id {
+				return i
+			}
+		}
+		return -1
+	}
+	assert.Less(t, position(late_id), position(today_id))
+	assert.Less(t, position(today_id), position(later_id))
+	assert.Contains(t, output.String(), "Overdue (")
+	assert.Regexp(t, `\[agenda_other\] +2020-01-01 +Late\n`, output.String())
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/db"
+)
+
+// Aliases are defined by the user in the `alias` section of user.cfg:
+//
+//	[alias]
+//	today = s prio:today sort:created
+//	done = + $1
+//	morning = `w work; s prio:today`
+//
+// `$1`..`$9` are replaced by the positional arguments of the alias call, `$@`
+// by all of them. If the template refers to none, the arguments are appended
+// to its end. Several commands are separated by `;`, such templates should be
+// quoted by backticks, otherwise the rest is read as a comment.
+//
+// Aliases may refer to other aliases, but not recursively. Built-in commands
+// cannot be shadowed, unless the alias name is prefixed by `!`, like
+// `!s = s -a`. Within its own expansion, such alias refers to the built-in.
+
+type Alias struct {
+	Name     string
+	Template string
+}
+
+var aliases = map[string]*Alias{}
+
+// Names of the aliases being expanded, outermost first.
+var alias_stack = []string{}
+
+var alias_param_regex = regexp.MustCompile(`\$[1-9@]`)
+
+func load_aliases() {
+	aliases = map[string]*Alias{}
+	for _, key := range bone.Config.Get_Keys("alias") {
+		name, shadow := strings.CutPrefix(key, "!")
+		if _, ok := COMMANDS[name]; ok && !shadow {
+			bone.Log_Error("Alias '%s' shadows the built-in command, prefix it by `!` to allow this.", name)
+			continue
+		}
+		aliases[name] = &Alias{Name: name, Template: bone.Config.Get_Raw_String("alias", key, "")}
+	}
+}
+
+// Returns nil, if the name should be called as the built-in command.
+func find_alias(name string) (*Alias, int) {
+	alias, ok := aliases[name]
+	if !ok {
+		return nil, common.OK
+	}
+	if !slices.Contains(alias_stack, name) {
+		return alias, common.OK
+	}
+	if _, ok := COMMANDS[name]; ok {
+		return nil, common.OK
+	}
+	bone.Log_Error("Alias recursion: %s -> %s", strings.Join(alias_stack, " -> "), name)
+	return nil, common.ALIAS_ERROR
+}
+
+func expand_alias(alias *Alias, args []string) (string, int) {
+	referred := false
+	missing := ""
+	input := alias_param_regex.ReplaceAllStringFunc(alias.Template, func(param string) string {
+		referred = true
+		if param == "$@" {
+			return strings.Join(args, " ")
+		}
+		i := int(param[1] - '1')
+		if i >= len(args) {
+			if missing == "" {
+				missing = param
+			}
+			return ""
+		}
+		return args[i]
+	})
+	if missing != "" {
+		bone.Log_Error("Alias '%s' expects argument %s.", alias.Name, missing)
+		return "", common.INPUT_ERROR
+	}
+	if !referred && len(args) > 0 {
+		input += " " + strings.Join(args, " ")
+	}
+	return input, common.OK
+}
+
+func call_alias(alias *Alias, args []string, tx *db.Tx) int {
+	input, e := expand_alias(alias, args)
+	if e != common.OK {
+		return e
+	}
+	alias_stack = append(alias_stack, alias.Name)
+	defer func() {
+		alias_stack = alias_stack[:len(alias_stack)-1]
+	}()
+	return process_batch(input, tx)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_expand_alias_ok(t *testing.T) {
+	input, e := expand_alias(&Alias{Name: "done", Template: "+ $1"}, []string{"3"})
+	assert.Equal(t, common.OK, e)
+	assert.Equal(t, "+ 3", input)
+
+	input, e = expand_alias(&Alias{Name: "today", Template: "s prio:today"}, []string{"-c"})
+	assert.Equal(t, common.OK, e)
+	assert.Equal(t, "s prio:today -c", input)
+
+	input, e = expand_alias(&Alias{Name: "mv", Template: "m $2 $1; s $@"}, []string{"work", "1"})
+	assert.Equal(t, common.OK, e)
+	assert.Equal(t, "m 1 work; s work 1", input)
+}
+
+func Test_expand_alias_error(t *testing.T) {
+	_, e := expand_alias(&Alias{Name: "done", Template: "+ $1"}, []string{})
+	assert.Equal(t, common.INPUT_ERROR, e)
+}
+
+func Test_call_alias_recursion_error(t *testing.T) {
+	aliases = map[string]*Alias{
+		"a1": {Name: "a1", Template: "s; a2"},
+		"a2": {Name: "a2", Template: "a1"},
+		"s":  {Name: "s", Template: "s -c"},
+	}
+	defer func() { aliases = map[string]*Alias{} }()
+
+	assert.Equal(t, common.ALIAS_ERROR, process_input("a1"))
+	assert.Empty(t, alias_stack)
+	// Shadowing alias refers to the built-in within its own expansion.
+	assert.Equal(t, common.OK, process_input("s"))
+}
+
+func Test_call_alias_ok(t *testing.T) {
+	project_id, output := with_project_output(t, "alias", "plain")
+	tx := db.Begin()
+	work_id, er := insert_project(tx, "work")
+	assert.Nil(t, er)
+	for _, project_id := range []int{project_id, work_id} {
+		task_id, er := insert_task(tx, "Urgent", project_id)
+		assert.Nil(t, er)
+		assert.Nil(t, set_task_priority(tx, task_id, TODAY_PRIORITY))
+		_, er = insert_task(tx, "Later", project_id)
+		assert.Nil(t, er)
+	}
+	assert.Nil(t, tx.Commit())
+
+	// Aliases of the doc comment.
+	aliases = map[string]*Alias{
+		"today":   {Name: "today", Template: "s prio:today sort:created"},
+		"done":    {Name: "done", Template: "+ $1"},
+		"morning": {Name: "morning", Template: "w work; s prio:today"},
+	}
+	defer func() { aliases = map[string]*Alias{} }()
+
+	assert.Equal(t, common.OK, process_input("today"))
+	assert.Equal(t, "|1| . Urgent\n", output.String())
+	assert.Equal(t, common.OK, process_input("done 1"))
+	output.Reset()
+	assert.Equal(t, common.OK, process_input("today"))
+	assert.Equal(t, "No tasks\n", output.String())
+
+	output.Reset()
+	assert.Equal(t, common.OK, process_input("morning"))
+	assert.Equal(t, "work", current_project_name)
+	assert.Equal(t, "|1| . Urgent\n", output.String())
+}
+
+func Test_command_summaries_ok(t *testing.T) {
+	for name := range COMMANDS {
+		assert.Contains(t, COMMAND_SUMMARIES, name)
+	}
+	assert.Len(t, COMMAND_SUMMARIES, len(COMMANDS))
+}
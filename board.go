@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/term"
+	"time"
+)
+
+// Kanban board of the current project: active tasks by priority and schedule,
+// and tasks completed and rejected in the recent days, `board.recent_days` of
+// user.cfg, 7 by default. Hooks are numbered column by column, so the cards
+// are completed, rejected and updated by them as usual. Columns are laid side
+// by side, and wrap to the next rows, if the terminal is too narrow.
+
+const BOARD_MIN_COLUMN_WIDTH = 24
+
+type Board_Column struct {
+	// State of the tasks.
+	State int
+	Title string
+	Tasks []*Task
+}
+
+type Board_Result struct {
+	Columns []*Board_Column
+}
+
+// Show the board of the current project.
+//
+// Args:
+//   - `-days N`: take completed and rejected tasks of the last days
+func board(ctx *Command_Context) int {
+	days := bone.Config.Get_Int("board", "recent_days", 7)
+	if value, ok := ctx.Get_Arg_Value("-days"); ok {
+		n, er := strconv.Atoi(value)
+		if er != nil || n < 0 {
+			bone.Log_Error("Expected number of days after `-days`, got '%s'.", value)
+			return common.INPUT_ERROR
+		}
+		days = n
+	}
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), now.Day()-days, 0, 0, 0, 0, now.Location()).Unix()
+
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	columns := []struct {
+		column *Board_Column
+		query  string
+		args   []any
+	}{
+		{
+			&Board_Column{State: ACTIVE, Title: "Active"},
+			"SELECT * FROM task WHERE state = $1 AND project_id = $2" +
+				" ORDER BY completion_priority DESC, schedule IS NULL ASC, schedule ASC, created_sec ASC, id ASC",
+			[]any{ACTIVE, current_project_id},
+		},
+		{
+			&Board_Column{State: COMPLETED, Title: "Completed"},
+			"SELECT * FROM task WHERE state = $1 AND project_id = $2 AND last_completed_sec >= $3" +
+				" ORDER BY last_completed_sec DESC, id DESC",
+			[]any{COMPLETED, current_project_id, since},
+		},
+		{
+			&Board_Column{State: REJECTED, Title: "Rejected"},
+			"SELECT * FROM task WHERE state = $1 AND project_id = $2 AND last_rejected_sec >= $3" +
+				" ORDER BY last_rejected_sec DESC, id DESC",
+			[]any{REJECTED, current_project_id, since},
+		},
+	}
+
+	result := &Board_Result{}
+	targets := []*Task{}
+	for _, c := range columns {
+		er := tx.Select(&c.column.Tasks, c.query, c.args...)
+		if er != nil {
+			bone.Log_Error("During task selection, an error occured: %s", er)
+			return common.SELECT_ERROR
+		}
+		targets = append(targets, c.column.Tasks...)
+		result.Columns = append(result.Columns, c.column)
+	}
+	set_hooks(targets)
+	emit(result)
+	return common.OK
+}
+
+// Lines of the card: hook, priority mark and the wrapped title, followed by
+// the schedule, if any.
+func board_card(t *Task, hook int, width int, theme *Theme) []string {
+	prefix := fmt.Sprintf("|%d| %s ", hook, theme.Get_Priority_Mark(t))
+	indent := strings.Repeat(" ", term.Width(prefix))
+	lines := []string{}
+	for i, line := range term.Wrap(t.Title, max(width-len(indent), 1)) {
+		if i == 0 {
+			lines = append(lines, prefix+line)
+		} else {
+			lines = append(lines, indent+line)
+		}
+	}
+	if t.Schedule != nil {
+		lines = append(lines, indent+*t.Schedule)
+	}
+	return lines
+}
+
+func draw_board(w io.Writer, r *Board_Result, columns int, theme *Theme) {
+	if columns == 0 {
+		columns = term.DEFAULT_COLUMNS
+	}
+	gap := " " + theme.Separator + " "
+	per_row := len(r.Columns)
+	column_width := func(n int) int {
+		return (columns - (n-1)*term.Width(gap)) / n
+	}
+	for per_row > 1 && column_width(per_row) < BOARD_MIN_COLUMN_WIDTH {
+		per_row--
+	}
+	width := max(column_width(per_row), 1)
+
+	blocks := [][]string{}
+	hook := 1
+	for _, c := range r.Columns {
+		title := fmt.Sprintf("%s (%d)", c.Title, len(c.Tasks))
+		block := []string{bone.Paint(title, theme.State_Colors[c.State]), strings.Repeat(theme.Axis_Line, width)}
+		for _, t := range c.Tasks {
+			block = append(block, board_card(t, hook, width, theme)...)
+			hook++
+		}
+		blocks = append(blocks, block)
+	}
+
+	for start := 0; start < len(blocks); start += per_row {
+		if start > 0 {
+			fmt.Fprintln(w)
+		}
+		row := blocks[start:min(start+per_row, len(blocks))]
+		height := 0
+		for _, block := range row {
+			height = max(height, len(block))
+		}
+		for i := range height {
+			cells := []string{}
+			for _, block := range row {
+				cell := ""
+				if i < len(block) {
+					cell = block[i]
+				}
+				cells = append(cells, term.Pad(cell, width))
+			}
+			// Separators of the ended columns are dropped.
+			for len(cells) > 1 && strings.TrimSpace(cells[len(cells)-1]) == "" {
+				cells = cells[:len(cells)-1]
+			}
+			fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, gap), " "))
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_draw_board_ok(t *testing.T) {
+	result := &Board_Result{Columns: []*Board_Column{
+		{State: ACTIVE, Title: "Active", Tasks: []*Task{
+			{Title: "Write the long report", Priority: TODAY_PRIORITY, Schedule: bone.Atop("2026-10-20")},
+			{Title: "Rest"},
+		}},
+		{State: COMPLETED, Title: "Completed", Tasks: []*Task{{Title: "Done", State: COMPLETED}}},
+	}}
+	var output bytes.Buffer
+	draw_board(&output, result, 53, plain_theme)
+	assert.Equal(
+		t,
+		"Active (2)                | Completed (1)\n"+
+			"------------------------- | -------------------------\n"+
+			"|1| !! Write the long     | |3| ~ Done\n"+
+			"       report\n"+
+			"       2026-10-20\n"+
+			"|2| ~ Rest\n",
+		output.String(),
+	)
+
+	// Columns wrap to the next row.
+	output.Reset()
+	draw_board(&output, result, 40, plain_theme)
+	assert.Equal(
+		t,
+		"Active (2)\n"+
+			"----------------------------------------\n"+
+			"|1| !! Write the long report\n"+
+			"       2026-10-20\n"+
+			"|2| ~ Rest\n"+
+			"\n"+
+			"Completed (1)\n"+
+			"----------------------------------------\n"+
+			"|3| ~ Done\n",
+		output.String(),
+	)
+}
+
+func Test_board_ok(t *testing.T) {
+	project_id, output := with_project_output(t, "board", "json")
+	tx := db.Begin()
+	now := time.Now().Unix()
+	tasks := []struct {
+		title string
+		state int
+		sec   int64
+	}{
+		{"Later", ACTIVE, 0},
+		{"Urgent", ACTIVE, 0},
+		{"Done", COMPLETED, now},
+		{"Done long ago", COMPLETED, now - 30*DAY_SEC},
+		{"Dropped", REJECTED, now},
+	}
+	for _, task := range tasks {
+		id, er := insert_task(tx, task.title, project_id)
+		assert.Nil(t, er)
+		_, er = tx.Exec(
+			"UPDATE task SET state = $1, last_completed_sec = $2, last_rejected_sec = $2 WHERE id = $3",
+			task.state, task.sec, id,
+		)
+		assert.Nil(t, er)
+		if task.title == "Urgent" {
+			assert.Nil(t, set_task_priority(tx, id, TODAY_PRIORITY))
+		}
+	}
+	assert.Nil(t, tx.Commit())
+
+	assert.Equal(t, common.OK, process_input("board"))
+	titles := []string{}
+	for _, hook := range hooks {
+		titles = append(titles, hook.(*Task).Title)
+	}
+	assert.Equal(t, []string{"Urgent", "Later", "Done", "Dropped"}, titles)
+	assert.Contains(t, output.String(), `"hook":4,"id":`)
+	assert.Contains(t, output.String(), `"column":"rejected"}`)
+
+	// Cards are completed by their hooks.
+	assert.Equal(t, common.OK, process_input("+ 1"))
+	assert.Equal(t, common.OK, process_input("board"))
+	states := map[string]int{}
+	for _, hook := range hooks {
+		states[hook.(*Task).Title] = hook.(*Task).State
+	}
+	assert.Equal(t, map[string]int{"Later": ACTIVE, "Urgent": COMPLETED, "Done": COMPLETED, "Dropped": REJECTED}, states)
+
+	assert.Equal(t, common.INPUT_ERROR, process_input("board -days x"))
+}
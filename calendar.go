@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/term"
+	"time"
+)
+
+// Month grid of the active scheduled tasks, with the count of the tasks per
+// day. Days before today with tasks are overdue. Exact time schedules fall on
+// their local days, tasks scheduled for the whole month are listed under the
+// grid, and ones scheduled for the whole year are skipped. Tasks of the
+// selected day are listed along, and the listed tasks take the hooks.
+
+var CALENDAR_WEEKDAYS = []string{"Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"}
+
+// Day number, the today mark, the count and the overdue mark.
+const CALENDAR_CELL_WIDTH = 8
+
+type Calendar_Result struct {
+	Type string `json:"type"`
+	// As `YYYY-MM`.
+	Month string `json:"month"`
+	// Tasks per day of the month.
+	Counts []int `json:"counts"`
+	// Days of the month with the overdue tasks.
+	Overdue []int `json:"overdue"`
+	// Day of the month, zero if it's another month.
+	Today int `json:"today,omitempty"`
+	// Selected day, zero for none.
+	Day         int     `json:"day,omitempty"`
+	Day_Tasks   []*Task `json:"-"`
+	Month_Tasks []*Task `json:"-"`
+}
+
+// Month and day of the calendar arguments: a month like `2026-10`, a date like
+// `2026-10-20` or `tomorrow`, or a day of the month like `20`. Returns zero
+// day, if it's not selected.
+func parse_calendar_args(args []string, now time.Time) (time.Time, int, bool) {
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	day := 0
+	for _, arg := range args {
+		if n, er := strconv.Atoi(arg); er == nil {
+			day = n
+			continue
+		}
+		date, ok := parse_query_date(arg, now)
+		if !ok || date.Precision == SCHEDULE_YEAR {
+			return time.Time{}, 0, false
+		}
+		month = time.Date(date.Start.Year(), date.Start.Month(), 1, 0, 0, 0, 0, now.Location())
+		if date.Precision == SCHEDULE_DAY {
+			day = date.Start.Day()
+		}
+	}
+	if day < 0 || day > month.AddDate(0, 1, -1).Day() {
+		return time.Time{}, 0, false
+	}
+	return month, day, true
+}
+
+// Local date of the scheduled day, false for the month and year schedules.
+func schedule_day(schedule *Schedule) (time.Time, bool) {
+	start := schedule.Start
+	switch schedule.Precision {
+	case SCHEDULE_DAY:
+	case SCHEDULE_TIME:
+		start = start.In(time.Local)
+	default:
+		return time.Time{}, false
+	}
+	return time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local), true
+}
+
+// Show the month calendar of the current project.
+//
+// Args:
+//   - month (default=current), `2026-10`, or the date of the day to select,
+//     like `2026-10-20` or `tomorrow`
+//   - day of the month to select, like `20`
+//   - `-everything`: show every project instead of the current one
+func calendar(ctx *Command_Context) int {
+	positional := []string{}
+	for _, arg := range ctx.Args {
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+		}
+	}
+	now := time.Now()
+	month, day, ok := parse_calendar_args(positional, now)
+	if !ok {
+		bone.Log_Error("Invalid month or day '%s', expected e.g. `2026-10` or `2026-10-20`.", strings.Join(positional, " "))
+		return common.INPUT_ERROR
+	}
+
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	query := "SELECT * FROM task WHERE state = $1 AND schedule IS NOT NULL"
+	args := []any{ACTIVE}
+	if !ctx.Has_Arg("-everything") {
+		query += " AND project_id = $2"
+		args = append(args, current_project_id)
+	}
+	tasks := []*Task{}
+	er := tx.Select(&tasks, query+" ORDER BY schedule ASC, completion_priority DESC, id ASC", args...)
+	if er != nil {
+		bone.Log_Error("During task selection, an error occured: %s", er)
+		return common.SELECT_ERROR
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	result := &Calendar_Result{
+		Type:    "calendar",
+		Month:   month.Format("2006-01"),
+		Counts:  make([]int, month.AddDate(0, 1, -1).Day()),
+		Overdue: []int{},
+		Day:     day,
+	}
+	if today.Year() == month.Year() && today.Month() == month.Month() {
+		result.Today = today.Day()
+	}
+	for _, t := range tasks {
+		schedule, ok := parse_schedule(*t.Schedule)
+		if !ok {
+			continue
+		}
+		if schedule.Precision == SCHEDULE_MONTH {
+			if schedule.String() == result.Month {
+				result.Month_Tasks = append(result.Month_Tasks, t)
+			}
+			continue
+		}
+		date, ok := schedule_day(schedule)
+		if !ok || date.Year() != month.Year() || date.Month() != month.Month() {
+			continue
+		}
+		result.Counts[date.Day()-1]++
+		if date.Before(today) && !slices.Contains(result.Overdue, date.Day()) {
+			result.Overdue = append(result.Overdue, date.Day())
+		}
+		if date.Day() == day {
+			result.Day_Tasks = append(result.Day_Tasks, t)
+		}
+	}
+	set_hooks(append(slices.Clone(result.Day_Tasks), result.Month_Tasks...))
+	emit(result)
+	return common.OK
+}
+
+func calendar_cell(r *Calendar_Result, day int, theme *Theme) string {
+	today_mark := " "
+	if day == r.Today {
+		today_mark = "*"
+	}
+	text := fmt.Sprintf("%2d%s", day, today_mark)
+	if r.Counts[day-1] > 0 {
+		text += fmt.Sprintf("(%d)", r.Counts[day-1])
+	}
+	padding := strings.Repeat(" ", max(CALENDAR_CELL_WIDTH-term.Width(text), 0))
+	if slices.Contains(r.Overdue, day) {
+		return bone.Paint(text+"!", theme.Error_Color) + padding[min(len(padding), 1):]
+	}
+	return text + padding
+}
+
+// Rows of the listed tasks, numbered by their hooks from the first one.
+func calendar_rows(tasks []*Task, first_hook int, theme *Theme, completion_mark func(t *Task) string) [][]string {
+	rows := [][]string{}
+	for i, t := range tasks {
+		row := []string{fmt.Sprintf("|%d|", first_hook+i), completion_mark(t), theme.Get_Priority_Mark(t)}
+		rows = append(rows, append(row, t.Title))
+	}
+	return rows
+}
+
+func draw_calendar(w io.Writer, r *Calendar_Result, columns int, theme *Theme, completion_mark func(t *Task) string) {
+	month, er := time.ParseInLocation("2006-01", r.Month, time.Local)
+	if er != nil {
+		return
+	}
+	fmt.Fprintln(w, month.Format("January 2006"))
+	header := []string{}
+	for _, weekday := range CALENDAR_WEEKDAYS {
+		header = append(header, term.Pad(weekday, CALENDAR_CELL_WIDTH))
+	}
+	fmt.Fprintln(w, strings.TrimRight(strings.Join(header, " "), " "))
+
+	// Monday is the first day of the week.
+	cells := make([]string, (int(month.Weekday())+6)%7)
+	for i := range cells {
+		cells[i] = strings.Repeat(" ", CALENDAR_CELL_WIDTH)
+	}
+	for day := 1; day <= len(r.Counts); day++ {
+		cells = append(cells, calendar_cell(r, day, theme))
+		if len(cells) == 7 || day == len(r.Counts) {
+			fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, " "), " "))
+			cells = []string{}
+		}
+	}
+
+	if r.Day > 0 {
+		day := month.AddDate(0, 0, r.Day-1)
+		fmt.Fprintf(w, "\n%s\n", day.Format("Mon 2006-01-02"))
+		if len(r.Day_Tasks) == 0 {
+			fmt.Fprint(w, "No tasks\n")
+		}
+		write_table(w, calendar_rows(r.Day_Tasks, 1, theme, completion_mark), columns)
+	}
+	if len(r.Month_Tasks) > 0 {
+		fmt.Fprint(w, "\nThis month\n")
+		write_table(w, calendar_rows(r.Month_Tasks, len(r.Day_Tasks)+1, theme, completion_mark), columns)
+	}
+}
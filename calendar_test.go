@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parse_calendar_args_ok(t *testing.T) {
+	now := time.Date(2026, 10, 19, 15, 0, 0, 0, time.Local)
+	month, day, ok := parse_calendar_args(nil, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local), month)
+	assert.Equal(t, 0, day)
+
+	month, day, ok = parse_calendar_args([]string{"2027-02", "28"}, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2027, 2, 1, 0, 0, 0, 0, time.Local), month)
+	assert.Equal(t, 28, day)
+
+	month, day, ok = parse_calendar_args([]string{"tomorrow"}, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local), month)
+	assert.Equal(t, 20, day)
+}
+
+func Test_parse_calendar_args_error(t *testing.T) {
+	now := time.Date(2026, 10, 19, 15, 0, 0, 0, time.Local)
+	for _, args := range [][]string{{"2026"}, {"soon"}, {"2027-02", "29"}, {"-1"}} {
+		_, _, ok := parse_calendar_args(args, now)
+		assert.False(t, ok, args)
+	}
+}
+
+func Test_draw_calendar_ok(t *testing.T) {
+	counts := make([]int, 28)
+	counts[0], counts[9], counts[27] = 1, 12, 2
+	var output bytes.Buffer
+	draw_calendar(&output, &Calendar_Result{
+		Month:       "2027-02",
+		Counts:      counts,
+		Overdue:     []int{1},
+		Today:       2,
+		Day:         10,
+		Day_Tasks:   []*Task{{Title: "Pay"}},
+		Month_Tasks: []*Task{{Title: "Plan", Priority: TODAY_PRIORITY}},
+	}, 0, plain_theme, plain_completion_mark)
+	assert.Equal(
+		t,
+		"February 2027\n"+
+			"Mo       Tu       We       Th       Fr       Sa       Su\n"+
+			" 1 (1)!   2*       3        4        5        6        7\n"+
+			" 8        9       10 (12)  11       12       13       14\n"+
+			"15       16       17       18       19       20       21\n"+
+			"22       23       24       25       26       27       28 (2)\n"+
+			"\nWed 2027-02-10\n"+
+			"|1| . ~ Pay\n"+
+			"\nThis month\n"+
+			"|2| . !! Plan\n",
+		output.String(),
+	)
+}
+
+func Test_calendar_ok(t *testing.T) {
+	now := time.Now()
+	date := func(days int) string {
+		return time.Date(now.Year(), now.Month(), now.Day()+days, 0, 0, 0, 0, time.Local).Format("2006-01-02")
+	}
+	project_id, output := with_project_output(t, "calendar", "json")
+	tx := db.Begin()
+	for title, schedule := range map[string]string{
+		"Late":    date(-40),
+		"Today":   date(0),
+		"Month":   now.Format("2006-01"),
+		"Year":    now.Format("2006"),
+		"Elapsed": date(0) + " 12:00:00",
+	} {
+		id, er := insert_task(tx, title, project_id)
+		assert.Nil(t, er)
+		assert.Nil(t, set_task_schedule(tx, id, bone.Atop(schedule)))
+	}
+	assert.Nil(t, tx.Commit())
+
+	assert.Equal(t, common.OK, process_input("cal "+date(-40)))
+	assert.Contains(t, output.String(), `"overdue":[`+time.Now().AddDate(0, 0, -40).Format("2")+`]`)
+	assert.Equal(t, "Late", hooks[0].(*Task).Title)
+	assert.Len(t, hooks, 1)
+
+	output.Reset()
+	assert.Equal(t, common.OK, process_input("cal"))
+	assert.Len(t, hooks, 1)
+	assert.Equal(t, "Month", hooks[0].(*Task).Title)
+	assert.Contains(t, output.String(), `"column":"month"`)
+
+	assert.Equal(t, common.INPUT_ERROR, process_input("cal 2026"))
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/term"
+	"time"
+)
+
+// Terminal charts of the task timestamps:
+//   - `daily`: tasks completed per day
+//   - `burndown`: tasks open at the end of each day
+//   - `weekly`: tasks created and completed per week
+//
+// Days are local, weeks start on Monday. Daily charts take as many days as fit
+// the terminal width. The history is approximated by the last completion and
+// rejection times, so reopened tasks count as open since their creation.
+
+var CHART_KINDS = []string{"daily", "burndown", "weekly"}
+
+const CHART_HEIGHT = 10
+const CHART_WEEKS = 8
+
+// Room for the value labels and the axis of the column charts.
+const CHART_MARGIN = 8
+
+type Chart_Series struct {
+	Name   string `json:"name"`
+	Values []int  `json:"values"`
+	// Task state, which colors the bars.
+	State int `json:"-"`
+}
+
+type Chart_Result struct {
+	Type  string `json:"type"`
+	Kind  string `json:"kind"`
+	Title string `json:"title"`
+	// Start of each bucket.
+	Labels []string        `json:"labels"`
+	Series []*Chart_Series `json:"series"`
+}
+
+// Midnights of the days, or Mondays of the weeks, the last bound is the end of
+// the current day or week.
+func chart_bounds(now time.Time, count int, weekly bool) []time.Time {
+	end := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	step := 1
+	if weekly {
+		step = 7
+		// Days since Monday, Sunday being the last day of the week.
+		end = end.AddDate(0, 0, (7-int(now.Weekday()))%7)
+	}
+	bounds := []time.Time{}
+	for i := count; i >= 0; i-- {
+		bounds = append(bounds, end.AddDate(0, 0, -i*step))
+	}
+	return bounds
+}
+
+func unix_bounds(bounds []time.Time) []int64 {
+	secs := []int64{}
+	for _, b := range bounds {
+		secs = append(secs, b.Unix())
+	}
+	return secs
+}
+
+func chart_labels(bounds []time.Time) []string {
+	labels := []string{}
+	for _, b := range bounds[:len(bounds)-1] {
+		labels = append(labels, b.Format("01-02"))
+	}
+	return labels
+}
+
+// Time the task was completed or rejected, zero if it's active.
+func task_closed_sec(t *Task) int {
+	switch t.State {
+	case COMPLETED:
+		return t.Last_Completed_Sec
+	case REJECTED:
+		return t.Last_Rejected_Sec
+	default:
+		return 0
+	}
+}
+
+// Seconds of the tasks, skipping zeros.
+func task_secs(tasks []*Task, task_sec func(t *Task) int) []int64 {
+	secs := []int64{}
+	for _, t := range tasks {
+		if sec := task_sec(t); sec > 0 {
+			secs = append(secs, int64(sec))
+		}
+	}
+	return secs
+}
+
+func completed_sec(t *Task) int {
+	if t.State != COMPLETED {
+		return 0
+	}
+	return t.Last_Completed_Sec
+}
+
+// Tasks open at the end of each bucket.
+func burndown(tasks []*Task, bounds []int64) []int {
+	open := 0
+	for _, t := range tasks {
+		closed := task_closed_sec(t)
+		if int64(t.Created_Sec) < bounds[0] && (closed == 0 || int64(closed) >= bounds[0]) {
+			open++
+		}
+	}
+	created := bone.Cumulative_Sum(bone.Bucket_Counts(task_secs(tasks, func(t *Task) int { return t.Created_Sec }), bounds))
+	closed := bone.Cumulative_Sum(bone.Bucket_Counts(task_secs(tasks, task_closed_sec), bounds))
+	values := []int{}
+	for i := range created {
+		values = append(values, open+created[i]-closed[i])
+	}
+	return values
+}
+
+// Draw the chart of the task timestamps.
+//
+// Args:
+//   - 1 (default="daily"): kind, one of `daily`, `burndown`, `weekly`
+//   - 2: number of days or weeks, by default as many days as fit the
+//     terminal, or 8 weeks
+//   - `-everything`: chart every project instead of the current one
+func chart(ctx *Command_Context) int {
+	positional := []string{}
+	for _, arg := range ctx.Args {
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+		}
+	}
+	kind := "daily"
+	if len(positional) > 0 {
+		kind = positional[0]
+	}
+	if !slices.Contains(CHART_KINDS, kind) {
+		bone.Log_Error("Unknown chart '%s', expected one of %s.", kind, strings.Join(CHART_KINDS, ", "))
+		return common.INPUT_ERROR
+	}
+	columns, _ := output_size(os.Stdout)
+	if columns == 0 {
+		columns = term.DEFAULT_COLUMNS
+	}
+	count := max(columns-CHART_MARGIN, 7)
+	if kind == "weekly" {
+		count = CHART_WEEKS
+	}
+	if len(positional) > 1 {
+		n, er := strconv.Atoi(positional[1])
+		if er != nil || n <= 0 {
+			bone.Log_Error("Expected positive number of days or weeks, got '%s'.", positional[1])
+			return common.INPUT_ERROR
+		}
+		count = n
+	}
+
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	tasks := []*Task{}
+	var er error
+	if ctx.Has_Arg("-everything") {
+		er = tx.Select(&tasks, "SELECT * FROM task")
+	} else {
+		er = tx.Select(&tasks, "SELECT * FROM task WHERE project_id = $1", current_project_id)
+	}
+	if er != nil {
+		bone.Log_Error("During task selection, an error occured: %s", er)
+		return common.SELECT_ERROR
+	}
+
+	bounds := chart_bounds(time.Now(), count, kind == "weekly")
+	secs := unix_bounds(bounds)
+	result := &Chart_Result{Type: "chart", Kind: kind, Labels: chart_labels(bounds)}
+	completed := &Chart_Series{
+		Name:   "completed",
+		Values: bone.Bucket_Counts(task_secs(tasks, completed_sec), secs),
+		State:  COMPLETED,
+	}
+	switch kind {
+	case "daily":
+		result.Title = "Completed per day"
+		result.Series = []*Chart_Series{completed}
+	case "burndown":
+		result.Title = "Open at the end of the day"
+		result.Series = []*Chart_Series{{Name: "open", Values: burndown(tasks, secs), State: ACTIVE}}
+	case "weekly":
+		result.Title = "Created and completed per week"
+		created := &Chart_Series{
+			Name:   "created",
+			Values: bone.Bucket_Counts(task_secs(tasks, func(t *Task) int { return t.Created_Sec }), secs),
+			State:  ACTIVE,
+		}
+		result.Series = []*Chart_Series{created, completed}
+	}
+	emit(result)
+	return common.OK
+}
+
+func draw_chart(w io.Writer, r *Chart_Result, columns int, theme *Theme) {
+	if columns == 0 {
+		columns = term.DEFAULT_COLUMNS
+	}
+	fmt.Fprintln(w, r.Title)
+	if len(r.Series) == 1 {
+		draw_column_chart(w, r.Labels, r.Series[0], columns, theme)
+		return
+	}
+	draw_bar_chart(w, r.Labels, r.Series, columns, theme)
+}
+
+// Columns of the series along the time axis, older ones are dropped if they
+// don't fit. The first and the last labels are written under the axis.
+func draw_column_chart(w io.Writer, labels []string, series *Chart_Series, columns int, theme *Theme) {
+	values := series.Values
+	top := strconv.Itoa(bone.Max_Int(values))
+	fit := max(columns-len(top)-2, 1)
+	if len(values) > fit {
+		values = values[len(values)-fit:]
+		labels = labels[len(labels)-fit:]
+	}
+	max_value := bone.Max_Int(values)
+	top = strconv.Itoa(max_value)
+
+	for row := CHART_HEIGHT; row >= 1; row-- {
+		label := ""
+		if row == CHART_HEIGHT {
+			label = top
+		}
+		var b strings.Builder
+		for _, v := range values {
+			if bone.Scale_Int(v, max_value, CHART_HEIGHT) >= row {
+				b.WriteString(theme.Bar)
+			} else {
+				b.WriteString(" ")
+			}
+		}
+		bars := strings.TrimRight(b.String(), " ")
+		fmt.Fprintf(w, "%*s %s%s\n", len(top), label, theme.Separator, bone.Paint(bars, theme.State_Colors[series.State]))
+	}
+	fmt.Fprintf(w, "%*s %s%s\n", len(top), "0", theme.Axis_Corner, strings.Repeat(theme.Axis_Line, len(values)))
+	if len(labels) == 0 {
+		return
+	}
+	first, last := labels[0], labels[len(labels)-1]
+	under := first
+	if gap := len(values) - len(first) - len(last); gap > 0 {
+		under += strings.Repeat(" ", gap) + last
+	}
+	fmt.Fprintf(w, "%s%s\n", strings.Repeat(" ", len(top)+2), under)
+}
+
+// Horizontal bars of every series per label, scaled to the common maximum.
+func draw_bar_chart(w io.Writer, labels []string, series []*Chart_Series, columns int, theme *Theme) {
+	label_width := 0
+	for _, label := range labels {
+		label_width = max(label_width, term.Width(label))
+	}
+	name_width := 0
+	max_value := 0
+	for _, s := range series {
+		name_width = max(name_width, term.Width(s.Name))
+		max_value = max(max_value, bone.Max_Int(s.Values))
+	}
+	value_width := len(strconv.Itoa(max_value))
+	bar_width := max(columns-label_width-name_width-value_width-3, 1)
+
+	for i, label := range labels {
+		for j, s := range series {
+			if j > 0 {
+				label = ""
+			}
+			value := s.Values[i]
+			bar := strings.Repeat(theme.Bar, bone.Scale_Int(value, max_value, bar_width))
+			fmt.Fprintf(
+				w,
+				"%s %s %s%d\n",
+				term.Pad(label, label_width),
+				term.Pad(s.Name, name_width),
+				bone.Paint(bar, theme.State_Colors[s.State]),
+				value,
+			)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_chart_bounds_ok(t *testing.T) {
+	// Monday.
+	now := time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC)
+	bounds := chart_bounds(now, 2, false)
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC),
+	}, bounds)
+	assert.Equal(t, []string{"10-18", "10-19"}, chart_labels(bounds))
+
+	bounds = chart_bounds(now, 1, true)
+	assert.Equal(t, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), bounds[0])
+	assert.Equal(t, time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC), bounds[1])
+	// Sunday is the last day of the week.
+	bounds = chart_bounds(now.AddDate(0, 0, -1), 1, true)
+	assert.Equal(t, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), bounds[0])
+}
+
+func Test_burndown_ok(t *testing.T) {
+	tasks := []*Task{
+		// Open before the chart, completed on the second day.
+		{State: COMPLETED, Created_Sec: 5, Last_Completed_Sec: 25},
+		// Closed before the chart.
+		{State: REJECTED, Created_Sec: 5, Last_Rejected_Sec: 8},
+		{State: ACTIVE, Created_Sec: 15},
+		{State: ACTIVE, Created_Sec: 35},
+	}
+	assert.Equal(t, []int{2, 1, 2}, burndown(tasks, []int64{10, 20, 30, 40}))
+}
+
+func Test_draw_chart_ok(t *testing.T) {
+	var output bytes.Buffer
+	draw_chart(&output, &Chart_Result{
+		Title:  "Completed per day",
+		Labels: []string{"10-16", "10-17", "10-18", "10-19"},
+		Series: []*Chart_Series{{Name: "completed", Values: []int{0, 2, 1, 4}}},
+	}, 20, plain_theme)
+	lines := strings.Split(output.String(), "\n")
+	assert.Len(t, lines, CHART_HEIGHT+4)
+	assert.Equal(t, "Completed per day", lines[0])
+	assert.Equal(t, "4 |   #", lines[1])
+	assert.Equal(t, "  | ###", lines[CHART_HEIGHT])
+	assert.Equal(t, "0 +----", lines[CHART_HEIGHT+1])
+	assert.Equal(t, "   10-16", lines[CHART_HEIGHT+2])
+
+	// Older days are dropped to fit.
+	output.Reset()
+	draw_chart(&output, &Chart_Result{
+		Labels: []string{"10-16", "10-17", "10-18", "10-19"},
+		Series: []*Chart_Series{{Values: []int{5, 1, 1, 1}}},
+	}, 6, plain_theme)
+	assert.Contains(t, output.String(), "\n1 |###\n")
+	assert.Contains(t, output.String(), "0 +---\n   10-17\n")
+
+	output.Reset()
+	draw_chart(&output, &Chart_Result{
+		Title:  "Created and completed per week",
+		Labels: []string{"10-12", "10-19"},
+		Series: []*Chart_Series{
+			{Name: "created", Values: []int{4, 2}},
+			{Name: "completed", Values: []int{2, 0}},
+		},
+	}, 30, plain_theme)
+	assert.Equal(
+		t,
+		"Created and completed per week\n"+
+			"10-12 created   ############4\n"+
+			"      completed ######2\n"+
+			"10-19 created   ######2\n"+
+			"      completed 0\n",
+		output.String(),
+	)
+}
+
+func Test_chart_ok(t *testing.T) {
+	project_id, output := with_project_output(t, "chart", "json")
+	tx := db.Begin()
+	task_id, er := insert_task(tx, "Done", project_id)
+	assert.Nil(t, er)
+	_, er = tx.Exec(
+		"UPDATE task SET state = $1, created_sec = $2, last_completed_sec = $3 WHERE id = $4",
+		COMPLETED, time.Now().AddDate(0, 0, -1).Unix(), time.Now().Unix(), task_id,
+	)
+	assert.Nil(t, er)
+	assert.Nil(t, tx.Commit())
+
+	assert.Equal(t, common.OK, process_input("chart daily 3"))
+	assert.Contains(t, output.String(), `"series":[{"name":"completed","values":[0,0,1]}]`)
+
+	output.Reset()
+	assert.Equal(t, common.OK, process_input("chart burndown 2"))
+	assert.Contains(t, output.String(), `"values":[1,0]`)
+
+	assert.Equal(t, common.INPUT_ERROR, process_input("chart pie"))
+	assert.Equal(t, common.INPUT_ERROR, process_input("chart weekly 0"))
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"tasker/internal/db"
+)
+
+// Flags of the commands, offered by the completion.
+var COMMAND_FLAGS = map[string][]string{
+	"s": SHOW_FLAGS,
+	"u": {"-r", "-d", "-m", "-n", "-np", "-na", "-e"},
+	"export": append(
+		[]string{"-o", "-project", "-everything", "-events"},
+		SHOW_FLAGS...,
+	),
+	"sync":    {"-unlink"},
+	"review":  {"-stale", "-everything", "-md", "-o"},
+	"chart":   {"-everything"},
+	"heatmap": {"-everything"},
+	"board":   {"-days"},
+	"cal":     {"-everything"},
+	"next":    {"-why", "-everything"},
+	"v":       append([]string{"-set", "-global", "-projects", "-d"}, SHOW_FLAGS...),
+}
+
+var SHOW_FLAGS = []string{
+	"-reverse",
+	"-a",
+	"-c",
+	"-r",
+	"-screated",
+	"-scompleted",
+	"-srejected",
+	"-ocompleted",
+	"-orejected",
+	"-limit",
+	"-offset",
+}
+
+// Values of the first argument of the commands.
+var COMMAND_ARGS = map[string][]string{
+	"s":      {"p"},
+	"a":      {"t", "p"},
+	"format": {"terminal", "plain", "json"},
+	"export": EXPORT_FORMATS,
+	"import": {"todotxt", "taskwarrior"},
+	"chart":  CHART_KINDS,
+}
+
+const TAG_PREFIX = "tag:"
+const PROJECT_PREFIX = "project:"
+
+// Complete the word before the cursor with command names, flags, values of
+// the first argument, project names for `w`, `m`, `u -m`, `export -project`
+// and `v -projects`, view names for `v`, tag names after `tag:` and project
+// names after `project:`.
+func complete_input(input string) (int, []string) {
+	start := strings.LastIndexAny(input, " ;") + 1
+	word := input[start:]
+	words := strings.Fields(input[strings.LastIndex(input, ";")+1 : start])
+
+	if len(words) == 0 {
+		return start, filter_prefix(command_names(), word)
+	}
+	command := words[0]
+	previous := words[len(words)-1]
+
+	candidates := []string{}
+	switch {
+	case strings.HasPrefix(word, TAG_PREFIX):
+		return start + len(TAG_PREFIX), filter_prefix(tag_names(), word[len(TAG_PREFIX):])
+	case strings.HasPrefix(word, PROJECT_PREFIX):
+		return start + len(PROJECT_PREFIX), filter_prefix(project_names(), word[len(PROJECT_PREFIX):])
+	case strings.HasPrefix(word, "-"):
+		candidates = COMMAND_FLAGS[command]
+	case command == "w" && len(words) == 1,
+		command == "m" && len(words) == 2,
+		command == "u" && previous == "-m",
+		command == "export" && previous == "-project",
+		command == "v" && previous == "-projects":
+		candidates = project_names()
+	case command == "h" && len(words) == 1:
+		candidates = command_names()
+	case command == "v" && len(words) == 1:
+		candidates = view_names()
+	case len(words) == 1:
+		candidates = COMMAND_ARGS[command]
+	}
+	return start, filter_prefix(candidates, word)
+}
+
+func filter_prefix(items []string, prefix string) []string {
+	filtered := []string{}
+	for _, item := range items {
+		if strings.HasPrefix(item, prefix) && !slices.Contains(filtered, item) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+func project_names() []string {
+	tx := db.Begin()
+	defer tx.Rollback()
+	names := []string{}
+	tx.Select(&names, "SELECT title FROM project ORDER BY title ASC")
+	return names
+}
+
+func tag_names() []string {
+	tx := db.Begin()
+	defer tx.Rollback()
+	names := []string{}
+	tx.Select(&names, "SELECT DISTINCT name FROM task_tag ORDER BY name ASC")
+	return names
+}
+
+func view_names() []string {
+	tx := db.Begin()
+	defer tx.Rollback()
+	names := []string{}
+	tx.Select(
+		&names,
+		"SELECT DISTINCT name FROM view WHERE project_id = $1 OR project_id IS NULL ORDER BY name ASC",
+		current_project_id,
+	)
+	return names
+}
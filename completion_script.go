@@ -0,0 +1,209 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+)
+
+// Shell completion scripts, printed by `tasker --completion bash|zsh|fish`.
+// Global flags and command names are generated from the registry, including
+// the aliases defined at the time of generation. The rest of the words of the
+// one-shot input are completed by calling back `tasker --complete INPUT`,
+// which prints the same candidates as the REPL completion, one per line. The
+// global flags of the completed command line are passed along, so the same
+// user directory is used.
+
+const COMPLETE_FLAG = "complete"
+
+var COMPLETION_SCRIPTS = map[string]func(w io.Writer){
+	"bash": write_bash_completion,
+	"zsh":  write_zsh_completion,
+	"fish": write_fish_completion,
+}
+
+func write_completion_script(w io.Writer, shell string) int {
+	write, ok := COMPLETION_SCRIPTS[shell]
+	if !ok {
+		bone.Log_Error("Unknown shell '%s', expected one of `bash`, `zsh`, `fish`.", shell)
+		return common.INPUT_ERROR
+	}
+	write(w)
+	return common.OK
+}
+
+// Print candidates as whole words, while the REPL completion may replace only
+// the end of the word, like the tag name after `tag:`.
+func write_complete_candidates(w io.Writer, input string) {
+	start, candidates := complete_input(input)
+	word_start := strings.LastIndexAny(input, " ;") + 1
+	for _, c := range candidates {
+		fmt.Fprintln(w, input[word_start:start]+c)
+	}
+}
+
+type Completion_Item struct {
+	Name        string
+	Description string
+}
+
+func completion_flags() []Completion_Item {
+	items := []Completion_Item{}
+	flag.VisitAll(func(f *flag.Flag) {
+		if f.Name != COMPLETE_FLAG {
+			items = append(items, Completion_Item{Name: "--" + f.Name, Description: f.Usage})
+		}
+	})
+	return items
+}
+
+func completion_commands() []Completion_Item {
+	items := []Completion_Item{}
+	for _, name := range command_names() {
+		items = append(items, Completion_Item{Name: name, Description: command_summary(name)})
+	}
+	return items
+}
+
+func item_names(items []Completion_Item) string {
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+// Wrap into single quotes for bash and zsh.
+func quote_posix(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Wrap into single quotes for fish, which escapes them by backslash.
+func quote_fish(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+func write_bash_completion(w io.Writer) {
+	fmt.Fprintf(w, `# Bash completion for tasker, load by: source <(tasker --completion bash)
+_tasker() {
+	local line="${COMP_LINE:0:COMP_POINT}"
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	if [[ "$line" != *" -- "* ]]; then
+		COMPREPLY=($(compgen -W %s -- "$cur"))
+		return
+	fi
+	local input="${line#* -- }"
+	if [[ "$input" != *" "* ]]; then
+		COMPREPLY=($(compgen -W %s -- "$cur"))
+		return
+	fi
+	# Words are broken by colons, so the part before the last one is dropped.
+	local word="${input##* }"
+	local prefix=""
+	if [[ "$COMP_WORDBREAKS" == *:* && "$word" == *:* ]]; then
+		prefix="${word%%:*}:"
+	fi
+	local -a global=(${line%%%% -- *})
+	local IFS=$'\n'
+	COMPREPLY=($("${global[@]}" --%s "$input" 2>/dev/null))
+	COMPREPLY=("${COMPREPLY[@]#"$prefix"}")
+}
+complete -F _tasker tasker
+`,
+		quote_posix("-- "+item_names(completion_flags())),
+		quote_posix(item_names(completion_commands())),
+		COMPLETE_FLAG,
+	)
+}
+
+// Names are escaped for `_describe`, which separates them from descriptions
+// by colons.
+func zsh_describe_items(items []Completion_Item) string {
+	lines := []string{}
+	for _, item := range items {
+		name := strings.ReplaceAll(item.Name, ":", `\:`)
+		lines = append(lines, "\t\t"+quote_posix(name+":"+item.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func write_zsh_completion(w io.Writer) {
+	fmt.Fprintf(w, `#compdef tasker
+# Zsh completion for tasker, load by: source <(tasker --completion zsh)
+_tasker() {
+	local -a options commands candidates
+	options=(
+		'--:run the one-shot command'
+%s
+	)
+	commands=(
+%s
+	)
+	local dash=${words[(i)--]}
+	if (( CURRENT <= dash )); then
+		_describe 'option' options
+		return
+	fi
+	if (( CURRENT == dash + 1 )); then
+		_describe 'command' commands
+		return
+	fi
+	local input="${(j: :)words[dash+1,CURRENT]}"
+	candidates=(${(f)"$(${words[1,dash-1]} --%s "$input" 2>/dev/null)"})
+	compadd -a candidates
+}
+compdef _tasker tasker
+`,
+		zsh_describe_items(completion_flags()),
+		zsh_describe_items(completion_commands()),
+		COMPLETE_FLAG,
+	)
+}
+
+func write_fish_completion(w io.Writer) {
+	fmt.Fprintf(w, `# Fish completion for tasker, load by: tasker --completion fish | source
+function __tasker_after_dash
+	contains -- -- (commandline -opc)
+end
+
+function __tasker_command_position
+	set -l tokens (commandline -opc)
+	test "$tokens[-1]" = "--"
+end
+
+function __tasker_complete
+	set -l tokens (commandline -opc)
+	set -l i (contains -i -- -- $tokens)
+	set -l input (string join ' ' $tokens[(math $i + 1)..-1] (commandline -ct))
+	set -l global $tokens[1]
+	if test $i -gt 2
+		set global $tokens[1..(math $i - 1)]
+	end
+	$global --%s "$input" 2>/dev/null
+end
+
+complete -c tasker -f
+complete -c tasker -n 'not __tasker_after_dash' -a '--' -d 'run the one-shot command'
+`, COMPLETE_FLAG)
+	for _, item := range completion_flags() {
+		fmt.Fprintf(
+			w,
+			"complete -c tasker -n 'not __tasker_after_dash' -l %s -d %s\n",
+			strings.TrimPrefix(item.Name, "--"),
+			quote_fish(item.Description),
+		)
+	}
+	for _, item := range completion_commands() {
+		fmt.Fprintf(
+			w,
+			"complete -c tasker -n '__tasker_command_position' -a %s -d %s\n",
+			quote_fish(item.Name),
+			quote_fish(item.Description),
+		)
+	}
+	fmt.Fprint(w, "complete -c tasker -n '__tasker_after_dash; and not __tasker_command_position' -a '(__tasker_complete)'\n")
+}
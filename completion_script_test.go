@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_write_completion_script_ok(t *testing.T) {
+	for shell := range COMPLETION_SCRIPTS {
+		var output bytes.Buffer
+		assert.Equal(t, common.OK, write_completion_script(&output, shell))
+		assert.Contains(t, output.String(), "--"+COMPLETE_FLAG)
+		assert.Contains(t, output.String(), "export")
+		assert.NotContains(t, output.String(), "%!")
+	}
+}
+
+func Test_write_completion_script_error(t *testing.T) {
+	var output bytes.Buffer
+	assert.Equal(t, common.INPUT_ERROR, write_completion_script(&output, "tcsh"))
+	assert.Empty(t, output.String())
+}
+
+func Test_write_complete_candidates_ok(t *testing.T) {
+	tx := db.Begin()
+	_, er := insert_project(tx, "completion_script")
+	assert.Nil(t, er)
+	assert.Nil(t, tx.Commit())
+
+	var output bytes.Buffer
+	write_complete_candidates(&output, "w completion_s")
+	assert.Contains(t, output.String(), "completion_script\n")
+}
+
+func Test_quote_ok(t *testing.T) {
+	assert.Equal(t, `'it'\''s'`, quote_posix("it's"))
+	assert.Equal(t, `'it\'s \\'`, quote_fish(`it's \`))
+}
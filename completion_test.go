@@ -0,0 +1,44 @@
+package main
+
+import (
+	"tasker/internal/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_complete_input_ok(t *testing.T) {
+	tx := db.Begin()
+	_, er := insert_project(tx, "completion_input")
+	assert.Nil(t, er)
+	assert.Nil(t, tx.Commit())
+
+	start, candidates := complete_input("for")
+	assert.Equal(t, 0, start)
+	assert.Equal(t, []string{"format"}, candidates)
+
+	start, candidates = complete_input("s -c; s -sc")
+	assert.Equal(t, 8, start)
+	assert.Equal(t, []string{"-screated", "-scompleted"}, candidates)
+
+	start, candidates = complete_input("w completion_in")
+	assert.Equal(t, 2, start)
+	assert.Equal(t, []string{"completion_input"}, candidates)
+
+	_, candidates = complete_input("u 1 -m ")
+	assert.Contains(t, candidates, "completion_input")
+
+	_, candidates = complete_input("a ")
+	assert.Equal(t, []string{"t", "p"}, candidates)
+
+	start, candidates = complete_input("s tag:")
+	assert.Equal(t, 6, start)
+	assert.NotNil(t, candidates)
+
+	start, candidates = complete_input("s project:completion_in")
+	assert.Equal(t, 10, start)
+	assert.Equal(t, []string{"completion_input"}, candidates)
+
+	_, candidates = complete_input("+ 1")
+	assert.Empty(t, candidates)
+}
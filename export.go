@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/db"
+
+	"github.com/google/uuid"
+)
+
+// Task representation for the exported data. Unlike `Task`, holds the
+// project title and the state name, so the output is readable without the
+// database at hand.
+type Export_Task struct {
+	Id                 int      `json:"id"`
+	Title              string   `json:"title"`
+	State              string   `json:"state"`
+	Created_Sec        int      `json:"created_sec"`
+	Last_Completed_Sec int      `json:"last_completed_sec"`
+	Last_Rejected_Sec  int      `json:"last_rejected_sec"`
+	Priority           int      `json:"priority"`
+	Schedule           *string  `json:"schedule"`
+	Effort_Sec         *int     `json:"effort_sec"`
+	Project_Id         int      `json:"project_id"`
+	Project            string   `json:"project"`
+	Tags               []string `json:"tags"`
+	// Fields of the imported task we have no columns for.
+	Extra json.RawMessage `json:"extra,omitempty"`
+}
+
+var EXPORT_FORMATS = []string{"json", "csv", "md", "todotxt", "taskwarrior", "ics"}
+
+var EXPORT_CSV_HEADER = []string{
+	"id",
+	"title",
+	"state",
+	"created_sec",
+	"last_completed_sec",
+	"last_rejected_sec",
+	"priority",
+	"schedule",
+	"effort_sec",
+	"project",
+	"tags",
+}
+
+// Stable identifier of the task for the formats which require one. Derived
+// from the task id, so repeated exports produce the same value.
+func task_uuid(task_id int) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(fmt.Sprintf("tasker:task:%d", task_id))).String()
+}
+
+func state_name(state int) string {
+	switch state {
+	case COMPLETED:
+		return "completed"
+	case REJECTED:
+		return "rejected"
+	default:
+		return "active"
+	}
+}
+
+func parse_state_name(name string) (int, bool) {
+	switch name {
+	case "active":
+		return ACTIVE, true
+	case "completed":
+		return COMPLETED, true
+	case "rejected":
+		return REJECTED, true
+	default:
+		return 0, false
+	}
+}
+
+// Arguments of the export, which are not its own options, form the query.
+func export_query_args(args []string) []string {
+	query_args := []string{}
+	for i := 0; i < len(args); i++ {
+		switch {
+		case i == 0 && slices.Contains(EXPORT_FORMATS, args[i]):
+		case args[i] == "-o", args[i] == "-project":
+			i++
+		case args[i] == "-everything", args[i] == "-events":
+		default:
+			query_args = append(query_args, args[i])
+		}
+	}
+	return query_args
+}
+
+// Export tasks to stdout or a file. Tasks are selected with the same query as
+// for `show`.
+//
+// Args:
+//   - 1 (default="json"): format, one of `json`, `csv`, `md`, `todotxt`,
+//     `taskwarrior`, `ics`; any other argument starts the query
+//   - `-o PATH`: write to the file instead of stdout
+//   - `-project NAME`: export the project instead of the current one
+//   - `-everything`: export tasks of every project
+//   - `-events`: for `ics`, write scheduled tasks as events instead of todos
+func export(ctx *Command_Context) int {
+	format := "json"
+	if len(ctx.Args) > 0 && slices.Contains(EXPORT_FORMATS, ctx.Args[0]) {
+		format = ctx.Args[0]
+	}
+
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	project_ids := []int{current_project_id}
+	if ctx.Has_Arg("-everything") {
+		project_ids = nil
+	} else if project_name, ok := ctx.Get_Arg_Value("-project"); ok {
+		project, er := get_project(tx, project_name)
+		if er != nil {
+			bone.Log_Error("Cannot find project '%s'.", project_name)
+			return common.NO_SUCH_PROJECT
+		}
+		project_ids = []int{project.Id}
+	}
+
+	query_args := export_query_args(ctx.Args)
+	filter, er := build_task_filter(query_args, project_ids)
+	if er != nil {
+		log_query_error(query_args, er)
+		return common.INPUT_ERROR
+	}
+	tasks := []*Task{}
+	er = tx.Select(&tasks, fmt.Sprintf("SELECT * FROM task %s %s", filter.Where, filter.Order), filter.Args...)
+	if er != nil {
+		bone.Log_Error("During task selection, an error occured: %s", er)
+		return common.SELECT_ERROR
+	}
+
+	export_tasks, er := to_export_tasks(tx, filter.Page(tasks))
+	if er != nil {
+		bone.Log_Error("During task conversion, an error occured: %s", er)
+		return common.SELECT_ERROR
+	}
+
+	var w io.Writer = os.Stdout
+	if path, ok := ctx.Get_Arg_Value("-o"); ok {
+		f, er := os.Create(path)
+		if er != nil {
+			bone.Log_Error("Cannot create file '%s', error: %s", path, er)
+			return common.FILE_ERROR
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch format {
+	case "csv":
+		return export_csv(w, export_tasks)
+	case "md":
+		return export_markdown(w, export_tasks)
+	case "todotxt":
+		return export_todotxt(w, export_tasks)
+	case "taskwarrior":
+		return export_taskwarrior(w, export_tasks)
+	case "ics":
+		return export_ics(w, export_tasks, ctx.Has_Arg("-events"))
+	default:
+		return export_json(w, export_tasks)
+	}
+}
+
+// Attach project titles and tags to the tasks.
+func to_export_tasks(tx *db.Tx, tasks []*Task) ([]*Export_Task, error) {
+	project_titles, er := get_project_titles(tx)
+	if er != nil {
+		return nil, er
+	}
+
+	tags, er := get_task_tags(tx)
+	if er != nil {
+		return nil, er
+	}
+
+	export_tasks := []*Export_Task{}
+	for _, t := range tasks {
+		task_tags, ok := tags[t.Id]
+		if !ok {
+			task_tags = []string{}
+		}
+		var extra json.RawMessage
+		if t.Extra != nil {
+			extra = json.RawMessage(*t.Extra)
+		}
+		export_tasks = append(export_tasks, &Export_Task{
+			Id:                 t.Id,
+			Title:              t.Title,
+			State:              state_name(t.State),
+			Created_Sec:        t.Created_Sec,
+			Last_Completed_Sec: t.Last_Completed_Sec,
+			Last_Rejected_Sec:  t.Last_Rejected_Sec,
+			Priority:           t.Priority,
+			Schedule:           t.Schedule,
+			Effort_Sec:         t.Effort_Sec,
+			Project_Id:         t.Project_Id,
+			Project:            project_titles[t.Project_Id],
+			Tags:               task_tags,
+			Extra:              extra,
+		})
+	}
+	return export_tasks, nil
+}
+
+func export_json(w io.Writer, tasks []*Export_Task) int {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	er := encoder.Encode(tasks)
+	if er != nil {
+		bone.Log_Error("During JSON encoding, an error occured: %s", er)
+		return common.FILE_ERROR
+	}
+	return common.OK
+}
+
+func export_csv(w io.Writer, tasks []*Export_Task) int {
+	writer := csv.NewWriter(w)
+	writer.Write(EXPORT_CSV_HEADER)
+	for _, t := range tasks {
+		schedule := ""
+		if t.Schedule != nil {
+			schedule = *t.Schedule
+		}
+		effort_sec := ""
+		if t.Effort_Sec != nil {
+			effort_sec = strconv.Itoa(*t.Effort_Sec)
+		}
+		writer.Write([]string{
+			strconv.Itoa(t.Id),
+			t.Title,
+			t.State,
+			strconv.Itoa(t.Created_Sec),
+			strconv.Itoa(t.Last_Completed_Sec),
+			strconv.Itoa(t.Last_Rejected_Sec),
+			strconv.Itoa(t.Priority),
+			schedule,
+			effort_sec,
+			t.Project,
+			strings.Join(t.Tags, ","),
+		})
+	}
+	writer.Flush()
+	er := writer.Error()
+	if er != nil {
+		bone.Log_Error("During CSV writing, an error occured: %s", er)
+		return common.FILE_ERROR
+	}
+	return common.OK
+}
+
+// Tasks are grouped under the project headings in the order of appearance.
+// Rejected tasks are checked and struck through.
+func export_markdown(w io.Writer, tasks []*Export_Task) int {
+	project_order := []string{}
+	project_tasks := map[string][]*Export_Task{}
+	for _, t := range tasks {
+		_, ok := project_tasks[t.Project]
+		if !ok {
+			project_order = append(project_order, t.Project)
+		}
+		project_tasks[t.Project] = append(project_tasks[t.Project], t)
+	}
+
+	for i, project := range project_order {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "# %s\n\n", project)
+		for _, t := range project_tasks[project] {
+			var er error
+			switch t.State {
+			case "completed":
+				_, er = fmt.Fprintf(w, "- [x] %s\n", t.Title)
+			case "rejected":
+				_, er = fmt.Fprintf(w, "- [x] ~~%s~~\n", t.Title)
+			default:
+				_, er = fmt.Fprintf(w, "- [ ] %s\n", t.Title)
+			}
+			if er != nil {
+				bone.Log_Error("During Markdown writing, an error occured: %s", er)
+				return common.FILE_ERROR
+			}
+		}
+	}
+	return common.OK
+}
+
+func export_todotxt(w io.Writer, tasks []*Export_Task) int {
+	for _, t := range tasks {
+		_, er := fmt.Fprintln(w, format_todotxt_line(t))
+		if er != nil {
+			bone.Log_Error("During todo.txt writing, an error occured: %s", er)
+			return common.FILE_ERROR
+		}
+	}
+	return common.OK
+}
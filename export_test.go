@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func export_fixture() []*Export_Task {
+	effort := 1800
+	return []*Export_Task{
+		{Id: 1, Title: "Buy milk, eggs", State: "active", Created_Sec: 100, Priority: TODAY_PRIORITY, Schedule: bone.Atop("2026-05-01"), Effort_Sec: &effort, Project_Id: 2, Project: "home", Tags: []string{"shop", "food"}},
+		{Id: 2, Title: `Read "Dune"`, State: "completed", Created_Sec: 200, Last_Completed_Sec: 300, Project_Id: 3, Project: "fun", Tags: []string{}},
+		{Id: 3, Title: "Fix sink", State: "rejected", Created_Sec: 400, Last_Rejected_Sec: 500, Project_Id: 2, Project: "home", Tags: []string{}},
+	}
+}
+
+func Test_export_json_ok(t *testing.T) {
+	var output bytes.Buffer
+	assert.Equal(t, common.OK, export_json(&output, export_fixture()[:2]))
+	expected := `[
+	{
+		"id": 1,
+		"title": "Buy milk, eggs",
+		"state": "active",
+		"created_sec": 100,
+		"last_completed_sec": 0,
+		"last_rejected_sec": 0,
+		"priority": 2,
+		"schedule": "2026-05-01",
+		"effort_sec": 1800,
+		"project_id": 2,
+		"project": "home",
+		"tags": [
+			"shop",
+			"food"
+		]
+	},
+	{
+		"id": 2,
+		"title": "Read \"Dune\"",
+		"state": "completed",
+		"created_sec": 200,
+		"last_completed_sec": 300,
+		"last_rejected_sec": 0,
+		"priority": 0,
+		"schedule": null,
+		"effort_sec": null,
+		"project_id": 3,
+		"project": "fun",
+		"tags": []
+	}
+]
+`
+	assert.Equal(t, expected, output.String())
+}
+
+func Test_export_csv_ok(t *testing.T) {
+	var output bytes.Buffer
+	assert.Equal(t, common.OK, export_csv(&output, export_fixture()))
+	expected := "id,title,state,created_sec,last_completed_sec,last_rejected_sec,priority,schedule,effort_sec,project,tags\n" +
+		`1,"Buy milk, eggs",active,100,0,0,2,2026-05-01,1800,home,"shop,food"` + "\n" +
+		`2,"Read ""Dune""",completed,200,300,0,0,,,fun,` + "\n" +
+		"3,Fix sink,rejected,400,0,500,0,,,home,\n"
+	assert.Equal(t, expected, output.String())
+}
+
+func Test_export_markdown_ok(t *testing.T) {
+	var output bytes.Buffer
+	assert.Equal(t, common.OK, export_markdown(&output, export_fixture()))
+	expected := "# home\n\n" +
+		"- [ ] Buy milk, eggs\n" +
+		"- [x] ~~Fix sink~~\n" +
+		"\n# fun\n\n" +
+		"- [x] Read \"Dune\"\n"
+	assert.Equal(t, expected, output.String())
+}
+
+func Test_export_query_args_ok(t *testing.T) {
+	assert.Equal(t, []string{"-c"}, export_query_args([]string{"csv", "-o", "out.csv", "-c", "-everything"}))
+	assert.Equal(t, []string{"state:completed"}, export_query_args([]string{"state:completed"}))
+}
+
+func Test_export_ok(t *testing.T) {
+	project_id, _ := with_project_output(t, "export", "plain")
+	tx := db.Begin()
+	_, er := insert_task(tx, "Active", project_id)
+	assert.Nil(t, er)
+	task_id, er := insert_task(tx, "Done", project_id)
+	assert.Nil(t, er)
+	assert.Nil(t, set_task_state(tx, task_id, COMPLETED))
+	assert.Nil(t, tx.Commit())
+
+	// Query without the format exports JSON.
+	path := filepath.Join(t.TempDir(), "export.json")
+	assert.Equal(t, common.OK, process_input("export state:completed -o "+path))
+	data, er := os.ReadFile(path)
+	assert.Nil(t, er)
+	tasks := []*Export_Task{}
+	assert.Nil(t, json.Unmarshal(data, &tasks))
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "Done", tasks[0].Title)
+	assert.Equal(t, "export", tasks[0].Project)
+
+	path = filepath.Join(t.TempDir(), "export.csv")
+	assert.Equal(t, common.OK, process_input("export csv -o "+path))
+	data, er = os.ReadFile(path)
+	assert.Nil(t, er)
+	assert.Contains(t, string(data), ",Active,active,")
+	assert.NotContains(t, string(data), "Done")
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/term"
+	"time"
+)
+
+// Heatmap of the completions over the last year, a column per week starting
+// on Monday and a row per weekday. Tasks are counted on the day of their last
+// completion, as there is no log of the earlier ones. Levels are the quarters
+// of the busiest day, drawn by the background colors of the theme, or by the
+// shades if the colors are disabled. Older weeks are dropped if they don't
+// fit the terminal.
+
+const HEATMAP_WEEKS = 53
+const HEATMAP_LEVELS = 4
+
+// Room for the weekday labels.
+const HEATMAP_LABEL_WIDTH = 4
+
+var HEATMAP_WEEKDAYS = [7]string{"Mon", "", "Wed", "", "Fri", "", ""}
+
+type Heatmap_Result struct {
+	Type string `json:"type"`
+	// Monday of the first week.
+	Start string `json:"start"`
+	// Completions per day from the start up to today.
+	Counts []int `json:"counts"`
+	Total  int   `json:"total"`
+	// Days with completions up to today, or yesterday, as today isn't over.
+	Current_Streak int `json:"current_streak"`
+	Longest_Streak int `json:"longest_streak"`
+	// Last day of the longest streak, empty without one.
+	Longest_End string `json:"longest_end,omitempty"`
+}
+
+// Current and longest runs of the days with completions, and the index of the
+// last day of the longest run, -1 without one. The current run may end the
+// day before the last one.
+func completion_streaks(counts []int) (int, int, int) {
+	longest, longest_end, run := 0, -1, 0
+	for i, count := range counts {
+		if count == 0 {
+			run = 0
+			continue
+		}
+		run++
+		if run > longest {
+			longest, longest_end = run, i
+		}
+	}
+	current := 0
+	end := len(counts) - 1
+	if end >= 0 && counts[end] == 0 {
+		end--
+	}
+	for i := end; i >= 0 && counts[i] > 0; i-- {
+		current++
+	}
+	return current, longest, longest_end
+}
+
+// Draw the heatmap of the completions.
+//
+// Args:
+//   - `-everything`: count every project instead of the current one
+func heatmap(ctx *Command_Context) int {
+	now := time.Now()
+	start := chart_bounds(now, HEATMAP_WEEKS, true)[0]
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	days := int(math.Round(today.Sub(start).Hours()/24)) + 1
+	bounds := unix_bounds(chart_bounds(now, days, false))
+
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	query := "SELECT last_completed_sec FROM task WHERE state = $1 AND last_completed_sec >= $2"
+	args := []any{COMPLETED, bounds[0]}
+	if !ctx.Has_Arg("-everything") {
+		query += " AND project_id = $3"
+		args = append(args, current_project_id)
+	}
+	secs := []int64{}
+	er := tx.Select(&secs, query, args...)
+	if er != nil {
+		bone.Log_Error("During task selection, an error occured: %s", er)
+		return common.SELECT_ERROR
+	}
+
+	counts := bone.Bucket_Counts(secs, bounds)
+	current, longest, longest_end := completion_streaks(counts)
+	result := &Heatmap_Result{
+		Type:           "heatmap",
+		Start:          start.Format("2006-01-02"),
+		Counts:         counts,
+		Current_Streak: current,
+		Longest_Streak: longest,
+	}
+	for _, count := range counts {
+		result.Total += count
+	}
+	if longest_end >= 0 {
+		result.Longest_End = start.AddDate(0, 0, longest_end).Format("2006-01-02")
+	}
+	emit(result)
+	return common.OK
+}
+
+func heatmap_cell(level int, theme *Theme) string {
+	if theme.Heat_Colors[level] != "" {
+		return bone.Paint("  ", theme.Heat_Colors[level])
+	}
+	return strings.Repeat(theme.Heat_Shades[level], 2)
+}
+
+func plural_days(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
+
+func draw_heatmap(w io.Writer, r *Heatmap_Result, columns int, theme *Theme) {
+	if columns == 0 {
+		columns = term.DEFAULT_COLUMNS
+	}
+	start, er := time.ParseInLocation("2006-01-02", r.Start, time.Local)
+	if er != nil {
+		return
+	}
+	weeks := (len(r.Counts) + 6) / 7
+	first_week := max(weeks-(columns-HEATMAP_LABEL_WIDTH)/2, 0)
+	max_count := bone.Max_Int(r.Counts)
+
+	// Months are labeled over their first weeks, if there is room.
+	months := []byte(strings.Repeat(" ", HEATMAP_LABEL_WIDTH+2*(weeks-first_week)+3))
+	label_end := 0
+	for week := first_week; week < weeks; week++ {
+		monday := start.AddDate(0, 0, week*7)
+		if week > first_week && monday.Month() == monday.AddDate(0, 0, -7).Month() {
+			continue
+		}
+		x := HEATMAP_LABEL_WIDTH + 2*(week-first_week)
+		if x < label_end {
+			continue
+		}
+		label_end = x + copy(months[x:], monday.Format("Jan")) + 1
+	}
+	fmt.Fprintln(w, strings.TrimRight(string(months), " "))
+
+	for weekday, label := range HEATMAP_WEEKDAYS {
+		var b strings.Builder
+		b.WriteString(term.Pad(label, HEATMAP_LABEL_WIDTH))
+		for week := first_week; week < weeks; week++ {
+			i := week*7 + weekday
+			if i >= len(r.Counts) {
+				break
+			}
+			level := 0
+			if r.Counts[i] > 0 {
+				level = bone.Scale_Int(r.Counts[i], max_count, HEATMAP_LEVELS)
+			}
+			b.WriteString(heatmap_cell(level, theme))
+		}
+		fmt.Fprintln(w, b.String())
+	}
+
+	legend := []string{}
+	for level := range HEATMAP_LEVELS + 1 {
+		legend = append(legend, heatmap_cell(level, theme))
+	}
+	fmt.Fprintf(w, "%sLess %s More\n", strings.Repeat(" ", HEATMAP_LABEL_WIDTH), strings.Join(legend, " "))
+
+	longest := plural_days(r.Longest_Streak)
+	if r.Longest_End != "" {
+		longest += " until " + r.Longest_End
+	}
+	fmt.Fprintf(
+		w,
+		"%d completed since %s, current streak %s, longest %s\n",
+		r.Total,
+		r.Start,
+		plural_days(r.Current_Streak),
+		longest,
+	)
+}
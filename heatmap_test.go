@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_completion_streaks_ok(t *testing.T) {
+	current, longest, end := completion_streaks([]int{1, 2, 1, 0, 1, 1, 0})
+	assert.Equal(t, []int{2, 3, 2}, []int{current, longest, end})
+	current, longest, end = completion_streaks([]int{1, 0, 1})
+	assert.Equal(t, []int{1, 1, 0}, []int{current, longest, end})
+	current, longest, end = completion_streaks([]int{0, 0})
+	assert.Equal(t, []int{0, 0, -1}, []int{current, longest, end})
+}
+
+func Test_draw_heatmap_ok(t *testing.T) {
+	var output bytes.Buffer
+	// From Monday to Wednesday of the next week.
+	draw_heatmap(&output, &Heatmap_Result{
+		Start:          "2026-09-28",
+		Counts:         []int{0, 4, 1, 2, 0, 0, 3, 1, 0, 4},
+		Total:          15,
+		Current_Streak: 1,
+		Longest_Streak: 3,
+		Longest_End:    "2026-10-01",
+	}, 80, plain_theme)
+	assert.Equal(
+		t,
+		"    Sep\n"+
+			"Mon ..::\n"+
+			"    ##..\n"+
+			"Wed ::##\n"+
+			"    ++\n"+
+			"Fri ..\n"+
+			"    ..\n"+
+			"    **\n"+
+			"    Less .. :: ++ ** ## More\n"+
+			"15 completed since 2026-09-28, current streak 1 day, longest 3 days until 2026-10-01\n",
+		output.String(),
+	)
+
+	// The older week is dropped.
+	output.Reset()
+	draw_heatmap(&output, &Heatmap_Result{Start: "2026-09-28", Counts: []int{0, 4, 1, 2, 0, 0, 3, 1}}, 7, plain_theme)
+	assert.True(t, strings.HasPrefix(output.String(), "    Oct\nMon ::\n    \n"))
+}
+
+func Test_heatmap_ok(t *testing.T) {
+	project_id, output := with_project_output(t, "heatmap", "json")
+	tx := db.Begin()
+	now := time.Now()
+	for _, days_ago := range []int{0, 1, 1, 3, 400} {
+		task_id, er := insert_task(tx, "Done", project_id)
+		assert.Nil(t, er)
+		_, er = tx.Exec(
+			"UPDATE task SET state = $1, last_completed_sec = $2 WHERE id = $3",
+			COMPLETED,
+			time.Date(now.Year(), now.Month(), now.Day()-days_ago, 12, 0, 0, 0, time.Local).Unix(),
+			task_id,
+		)
+		assert.Nil(t, er)
+	}
+	assert.Nil(t, tx.Commit())
+
+	assert.Equal(t, common.OK, process_input("heatmap"))
+	assert.Contains(t, output.String(), `"total":4,"current_streak":2,"longest_streak":2`)
+	assert.Contains(t, output.String(), `1,0,2,1]`)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+)
+
+// One-line summaries of the built-in commands.
+var COMMAND_SUMMARIES = map[string]string{
+	"+":       "complete the task by hook",
+	"-":       "reject the task by hook",
+	".":       "add the task to the current project",
+	"s":       "show tasks matching the query, `p` for projects",
+	"a":       "add `t`ask or `p`roject",
+	"u":       "update tasks by hooks",
+	"w":       "switch to the project",
+	"i":       "show statistics of the current project",
+	"f":       "find tasks",
+	"m":       "move the task by hook to the project",
+	"h":       "show this help",
+	"v":       "run, list, save or delete the `show` views",
+	"format":  "switch the output format",
+	"export":  "export tasks to the file",
+	"import":  "import tasks from the file",
+	"sync":    "sync the current project with the Markdown checklist",
+	"review":  "review the work done in the period",
+	"chart":   "draw the chart of the daily completions, burndown or weekly progress",
+	"heatmap": "draw the heatmap of the completions over the last year",
+	"board":   "show the board of active, recently completed and rejected tasks",
+	"cal":     "show the month calendar of the scheduled tasks, or the tasks of the day",
+	"agenda":  "show overdue, today, this week and later tasks across the projects",
+	"next":    "recommend the tasks to work on, `-why` explains the scores",
+}
+
+// Built-in commands followed by the aliases, each sorted by name.
+func command_names() []string {
+	names := []string{}
+	for name := range COMMAND_SUMMARIES {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	alias_names := []string{}
+	for name := range aliases {
+		if _, ok := COMMAND_SUMMARIES[name]; !ok {
+			alias_names = append(alias_names, name)
+		}
+	}
+	slices.Sort(alias_names)
+	return append(names, alias_names...)
+}
+
+// Show the commands and the aliases.
+//
+// Args:
+//   - 1: command or alias name to show only it
+func help(ctx *Command_Context) int {
+	names := command_names()
+	if len(ctx.Args) > 0 {
+		if !slices.Contains(names, ctx.Args[0]) {
+			bone.Log_Error("Unknown command '%s'.", ctx.Args[0])
+			return common.UNKNOWN_COMMAND
+		}
+		names = []string{ctx.Args[0]}
+	}
+	for _, name := range names {
+		emit_message("%-8s %s", name, command_summary(name))
+	}
+	return common.OK
+}
+
+func command_summary(name string) string {
+	if alias, ok := aliases[name]; ok {
+		return fmt.Sprintf("alias for `%s`", alias.Template)
+	}
+	return COMMAND_SUMMARIES[name]
+}
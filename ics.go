@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/ical"
+	"time"
+)
+
+const ICS_PRODID = "-//slimebones//tasker//EN"
+
+func ics_status(state string, as_event bool) string {
+	switch state {
+	case "completed":
+		if as_event {
+			return "CONFIRMED"
+		}
+		return "COMPLETED"
+	case "rejected":
+		return "CANCELLED"
+	default:
+		if as_event {
+			return "CONFIRMED"
+		}
+		return "NEEDS-ACTION"
+	}
+}
+
+// RFC 5545 priorities go from 1 (highest) to 9 (lowest).
+func ics_priority(priority int) string {
+	switch priority {
+	case TODAY_PRIORITY:
+		return "1"
+	case THIS_WEEK_PRIORITY:
+		return "5"
+	default:
+		return "9"
+	}
+}
+
+func ics_date(t time.Time) (string, ical.Param) {
+	return t.Format(ical.DATE_LAYOUT), ical.Param{Name: "VALUE", Value: "DATE"}
+}
+
+func ics_date_time(t time.Time) string {
+	return t.UTC().Format(ical.DATE_TIME_LAYOUT)
+}
+
+// Build the calendar out of the scheduled tasks, the rest are skipped. Each
+// task becomes VTODO, or VEVENT if `as_events` is set. Schedules without the
+// exact time become all-day entries spanning the scheduled day, month or year.
+func build_calendar(tasks []*Export_Task, as_events bool, now time.Time) (*ical.Component, error) {
+	calendar := ical.New_Component("VCALENDAR").
+		Add("VERSION", "2.0").
+		Add("PRODID", ICS_PRODID).
+		Add("CALSCALE", "GREGORIAN")
+
+	for _, t := range tasks {
+		if t.Schedule == nil {
+			continue
+		}
+		schedule, ok := parse_schedule(*t.Schedule)
+		if !ok {
+			return nil, fmt.Errorf("task %d has malformed schedule '%s'", t.Id, *t.Schedule)
+		}
+
+		name := "VTODO"
+		if as_events {
+			name = "VEVENT"
+		}
+		c := ical.New_Component(name).
+			Add("UID", task_uuid(t.Id)+"@tasker").
+			Add("DTSTAMP", ics_date_time(now)).
+			Add("CREATED", ics_date_time(time.Unix(int64(t.Created_Sec), 0))).
+			Add_Text("SUMMARY", t.Title).
+			Add("STATUS", ics_status(t.State, as_events)).
+			Add("PRIORITY", ics_priority(t.Priority))
+
+		categories := []string{}
+		if t.Project != "" {
+			categories = append(categories, ical.Escape_Text(t.Project))
+		}
+		for _, tag := range t.Tags {
+			categories = append(categories, ical.Escape_Text(tag))
+		}
+		if len(categories) > 0 {
+			value := categories[0]
+			for _, category := range categories[1:] {
+				value += "," + category
+			}
+			c.Add("CATEGORIES", value)
+		}
+
+		switch {
+		case !schedule.Is_All_Day():
+			if as_events {
+				c.Add("DTSTART", ics_date_time(schedule.Start))
+			} else {
+				c.Add("DUE", ics_date_time(schedule.Start))
+			}
+		case as_events:
+			// All-day events end exclusively.
+			start, param := ics_date(schedule.Start)
+			c.Add("DTSTART", start, param)
+			end, param := ics_date(schedule.End())
+			c.Add("DTEND", end, param)
+		case schedule.Precision == SCHEDULE_DAY:
+			// Todo due must be later than its start, so the single day has
+			// no start at all.
+			due, param := ics_date(schedule.Start)
+			c.Add("DUE", due, param)
+		default:
+			start, param := ics_date(schedule.Start)
+			c.Add("DTSTART", start, param)
+			due, param := ics_date(schedule.End().AddDate(0, 0, -1))
+			c.Add("DUE", due, param)
+		}
+
+		if !as_events && t.State == "completed" && t.Last_Completed_Sec > 0 {
+			c.Add("COMPLETED", ics_date_time(time.Unix(int64(t.Last_Completed_Sec), 0)))
+		}
+
+		calendar.Components = append(calendar.Components, c)
+	}
+	return calendar, nil
+}
+
+func export_ics(w io.Writer, tasks []*Export_Task, as_events bool) int {
+	calendar, er := build_calendar(tasks, as_events, time.Now())
+	if er != nil {
+		bone.Log_Error("During iCalendar conversion, an error occured: %s", er)
+		return common.CONVERSION_ERROR
+	}
+	er = ical.Encode(w, calendar)
+	if er != nil {
+		bone.Log_Error("During iCalendar writing, an error occured: %s", er)
+		return common.FILE_ERROR
+	}
+	return common.OK
+}
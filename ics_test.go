@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/ical"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_calendar_round_trip_ok(t *testing.T) {
+	tasks := []*Export_Task{
+		{Id: 1, Title: "Plan, then ship; v2", State: "active", Priority: TODAY_PRIORITY, Schedule: bone.Atop("2026-05"), Project: "work", Tags: []string{"q2"}},
+		{Id: 2, Title: "Call", State: "completed", Last_Completed_Sec: 1777000000, Schedule: bone.Atop("2026-05-03 10:30:00")},
+		{Id: 3, Title: "Unscheduled", State: "active"},
+		{Id: 4, Title: "Dentist", State: "rejected", Schedule: bone.Atop("2026-05-04")},
+	}
+	now := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	calendar, er := build_calendar(tasks, false, now)
+	assert.Nil(t, er)
+	var b strings.Builder
+	assert.Nil(t, ical.Encode(&b, calendar))
+	decoded, er := ical.Decode(strings.NewReader(b.String()))
+	assert.Nil(t, er)
+
+	todos := decoded.Components
+	assert.Len(t, todos, 3)
+	assert.Equal(t, "Plan, then ship; v2", ical.Unescape_Text(todos[0].Get("SUMMARY").Value))
+	assert.Equal(t, "20260501", todos[0].Get("DTSTART").Value)
+	assert.Equal(t, "20260531", todos[0].Get("DUE").Value)
+	assert.Equal(t, "work,q2", todos[0].Get("CATEGORIES").Value)
+	assert.Equal(t, "1", todos[0].Get("PRIORITY").Value)
+	assert.Equal(t, "20260503T103000Z", todos[1].Get("DUE").Value)
+	assert.Equal(t, "COMPLETED", todos[1].Get("STATUS").Value)
+	assert.Equal(t, "CANCELLED", todos[2].Get("STATUS").Value)
+
+	// Identifiers are stable between exports.
+	again, _ := build_calendar(tasks, true, now.Add(time.Hour))
+	assert.Equal(t, todos[0].Get("UID").Value, again.Components[0].Get("UID").Value)
+	assert.Equal(t, "VEVENT", again.Components[0].Name)
+	assert.Equal(t, "20260601", again.Components[0].Get("DTEND").Value)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/db"
+)
+
+// Task parsed out of the imported file. Project is referenced by the title,
+// since the file knows nothing about our ids. Empty project stands for the
+// current one.
+type Import_Task struct {
+	Task
+	Project string
+	// Title used instead of the project one, if there is no such project.
+	Project_Fallback string
+	Tags             []string
+}
+
+// Import tasks from a file. The whole file is parsed before touching the
+// database, so nothing is imported if any entry is malformed.
+//
+// Args:
+//   - 1: format, one of `todotxt`, `taskwarrior`
+//   - 2: path to the file
+func import_tasks(ctx *Command_Context) int {
+	if len(ctx.Args) != 2 {
+		bone.Log_Error("Expected import format and file path.")
+		return common.INPUT_ERROR
+	}
+	format := ctx.Args[0]
+	path := ctx.Args[1]
+
+	body, er := os.ReadFile(path)
+	if er != nil {
+		bone.Log_Error("Cannot read file '%s', error: %s", path, er)
+		return common.FILE_ERROR
+	}
+
+	var tasks []*Import_Task
+	switch format {
+	case "todotxt":
+		tasks, er = parse_todotxt(string(body))
+	case "taskwarrior":
+		tasks, er = parse_taskwarrior(string(body))
+	default:
+		bone.Log_Error("Unknown import format '%s'.", format)
+		return common.INPUT_ERROR
+	}
+	if er != nil {
+		bone.Log_Error("Cannot parse file '%s', nothing is imported: %s", path, er)
+		return common.CONVERSION_ERROR
+	}
+
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	e := apply_import(tx, tasks)
+	if e > 0 {
+		return e
+	}
+
+	er = tx.Commit()
+	if er != nil {
+		return common.COMMIT_ERROR
+	}
+	return common.OK
+}
+
+// Insert imported tasks. Missing projects are created. A task with the same
+// title as an existing one within the same project is considered a duplicate,
+// it is reported and skipped.
+func apply_import(tx *db.Tx, tasks []*Import_Task) int {
+	projects := []*Project{}
+	er := tx.Select(&projects, "SELECT * FROM project")
+	if er != nil {
+		bone.Log_Error("During project selection, an error occured: %s", er)
+		return common.SELECT_ERROR
+	}
+	project_ids := map[string]int{}
+	for _, p := range projects {
+		project_ids[p.Title] = p.Id
+	}
+
+	existing := []*Task{}
+	er = tx.Select(&existing, "SELECT * FROM task")
+	if er != nil {
+		bone.Log_Error("During task selection, an error occured: %s", er)
+		return common.SELECT_ERROR
+	}
+	seen := map[string]bool{}
+	for _, t := range existing {
+		seen[fmt.Sprintf("%d:%s", t.Project_Id, t.Title)] = true
+	}
+
+	imported := 0
+	duplicates := 0
+	for _, t := range tasks {
+		project_name := t.Project
+		if project_name == "" {
+			project_name = current_project_name
+		}
+		project_id, ok := project_ids[project_name]
+		if !ok && t.Project_Fallback != "" {
+			project_name = t.Project_Fallback
+			project_id, ok = project_ids[project_name]
+		}
+		if !ok {
+			result, er := tx.Exec("INSERT INTO project (title) VALUES ($1)", project_name)
+			if er != nil {
+				bone.Log_Error("During project '%s' creation, an error occured: %s", project_name, er)
+				return common.INSERT_ERROR
+			}
+			id, _ := result.LastInsertId()
+			project_id = int(id)
+			project_ids[project_name] = project_id
+			emit_message("Created project '%s'.", project_name)
+		}
+
+		key := fmt.Sprintf("%d:%s", project_id, t.Title)
+		if seen[key] {
+			emit_message("Duplicate task '%s' in project '%s', skipped.", t.Title, project_name)
+			duplicates++
+			continue
+		}
+		seen[key] = true
+
+		created_sec := t.Created_Sec
+		if created_sec == 0 {
+			created_sec = int(bone.Utc())
+		}
+		result, er := tx.Exec(
+			`INSERT INTO task (
+				title, state, created_sec, last_completed_sec, last_rejected_sec,
+				completion_priority, schedule, project_id, extra
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			t.Title,
+			t.State,
+			created_sec,
+			t.Last_Completed_Sec,
+			t.Last_Rejected_Sec,
+			t.Priority,
+			t.Schedule,
+			project_id,
+			t.Extra,
+		)
+		if er != nil {
+			bone.Log_Error("During task '%s' import, an error occured: %s", t.Title, er)
+			return common.INSERT_ERROR
+		}
+		id, _ := result.LastInsertId()
+		er = set_task_tags(tx, int(id), t.Tags)
+		if er != nil {
+			bone.Log_Error("During task '%s' tagging, an error occured: %s", t.Title, er)
+			return common.INSERT_ERROR
+		}
+		imported++
+	}
+
+	emit_message("Imported %d tasks, skipped %d duplicates.", imported, duplicates)
+	return common.OK
+}
@@ -108,17 +108,15 @@ func Init(project_name string) int {
 
 func Assert(condition bool, messageAndArgs ...any) {
 	if !condition {
-		const RED = "\033[91m"
-		const RESET = "\033[0m"
 		// 1 means get the caller of this function.
 		pc, file, line, ok := runtime.Caller(1)
 		var message string
 		if !ok {
-			message = fmt.Sprintf("%sASSERT%s\n", RED, RESET)
+			message = Paint("ASSERT", Error_Color) + "\n"
 		} else {
 			// Get the function name.
 			funcName := runtime.FuncForPC(pc).Name()
-			message = fmt.Sprintf("%s:%d:(%s): %sASSERT%s\n", file, line, funcName, RED, RESET)
+			message = fmt.Sprintf("%s:%d:(%s): %s\n", file, line, funcName, Paint("ASSERT", Error_Color))
 		}
 		if len(messageAndArgs) > 0 {
 			submessage, ok := messageAndArgs[0].(string)
@@ -262,6 +260,29 @@ func (cfg *App_Config) Get_Float(module string, key string, d float64) float64 {
 	return value_float
 }
 
+// Same as `Get_String`, but environs are not activated, so the value can
+// hold `$` placeholders of its own.
+func (cfg *App_Config) Get_Raw_String(module string, key string, d string) string {
+	moduleData, e := cfg.data.GetSection(module)
+	if e != nil {
+		return d
+	}
+	valueKey, e := moduleData.GetKey(key)
+	if e != nil {
+		return d
+	}
+	return valueKey.String()
+}
+
+// Keys of the module in the order of definition.
+func (cfg *App_Config) Get_Keys(module string) []string {
+	moduleData, e := cfg.data.GetSection(module)
+	if e != nil {
+		return []string{}
+	}
+	return moduleData.KeyStrings()
+}
+
 var Config *App_Config
 
 func Testing() bool {
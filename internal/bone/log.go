@@ -1,16 +1,35 @@
 package bone
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"os"
+)
 
 // @todo use loguru-like sinks
 
+// Writer of the info logs. It's replaced by stderr when stdout is reserved for
+// the protocol, e.g. in the JSON-RPC mode.
+var Log_Writer io.Writer = os.Stdout
+
 func Log(message string, args ...any) {
-	fmt.Printf(message+"\n", args...)
+	fmt.Fprintf(Log_Writer, message+"\n", args...)
+}
+
+// SGR code of the error label, empty one leaves it uncolored.
+var Error_Color = "91"
+
+// Color the text by the SGR code, e.g. `31` or `1;31`. Empty code leaves the
+// text as is.
+func Paint(text string, code string) string {
+	if code == "" {
+		return text
+	}
+	return "\033[" + code + "m" + text + "\033[0m"
 }
 
 func Log_Error(message string, args ...any) {
-	const RED = "\033[91m"
-	const RESET = "\033[0m"
 	message = fmt.Sprintf(message, args...)
-	fmt.Printf("%sERROR%s: %s\n", RED, RESET, message)
+	// Errors go to stderr, so they don't mix with the output of one-shot commands.
+	fmt.Fprintf(os.Stderr, "%s: %s\n", Paint("ERROR", Error_Color), message)
 }
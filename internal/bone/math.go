@@ -1,6 +1,9 @@
 package bone
 
-import "math"
+import (
+	"math"
+	"sort"
+)
 
 type Vector2 struct {
 	X float64
@@ -65,3 +68,52 @@ func (v *Vector2i) Dot(other Vector2i) int {
 func PowInt(x int, y int) int {
 	return int(math.Pow(float64(x), float64(y)))
 }
+
+// Count the values falling into the buckets between the ascending bounds, so
+// there is one bucket less than the bounds. Bucket includes its lower bound.
+// Values out of the bounds are skipped.
+func Bucket_Counts(values []int64, bounds []int64) []int {
+	if len(bounds) < 2 {
+		return []int{}
+	}
+	counts := make([]int, len(bounds)-1)
+	for _, v := range values {
+		i := sort.Search(len(bounds), func(i int) bool { return bounds[i] > v }) - 1
+		if i >= 0 && i < len(counts) {
+			counts[i]++
+		}
+	}
+	return counts
+}
+
+// Running totals of the values.
+func Cumulative_Sum(values []int) []int {
+	sums := make([]int, len(values))
+	total := 0
+	for i, v := range values {
+		total += v
+		sums[i] = total
+	}
+	return sums
+}
+
+// Maximum of the values, zero for none.
+func Max_Int(values []int) int {
+	m := 0
+	for i, v := range values {
+		if i == 0 || v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+// Scale the value from `[0, max_value]` to `[0, size]`, rounding to the
+// nearest. Positive values take at least one unit, so they stay visible.
+func Scale_Int(value int, max_value int, size int) int {
+	if value <= 0 || max_value <= 0 {
+		return 0
+	}
+	scaled := int(math.Round(float64(value) * float64(size) / float64(max_value)))
+	return min(max(scaled, 1), size)
+}
@@ -0,0 +1,30 @@
+package bone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_bucket_counts_ok(t *testing.T) {
+	assert.Equal(t, []int{2, 0, 1}, Bucket_Counts([]int64{-1, 0, 9, 25, 30}, []int64{0, 10, 20, 30}))
+	assert.Equal(t, []int{}, Bucket_Counts([]int64{1}, []int64{0}))
+}
+
+func Test_cumulative_sum_ok(t *testing.T) {
+	assert.Equal(t, []int{1, 1, 4}, Cumulative_Sum([]int{1, 0, 3}))
+}
+
+func Test_max_int_ok(t *testing.T) {
+	assert.Equal(t, 0, Max_Int([]int{}))
+	assert.Equal(t, -1, Max_Int([]int{-3, -1}))
+	assert.Equal(t, 7, Max_Int([]int{2, 7, 5}))
+}
+
+func Test_scale_int_ok(t *testing.T) {
+	assert.Equal(t, 10, Scale_Int(50, 50, 10))
+	assert.Equal(t, 5, Scale_Int(25, 50, 10))
+	assert.Equal(t, 1, Scale_Int(1, 1000, 10))
+	assert.Equal(t, 0, Scale_Int(0, 50, 10))
+	assert.Equal(t, 0, Scale_Int(5, 0, 10))
+}
@@ -15,4 +15,11 @@ const (
 	NO_SUCH_PROJECT
 	CONVERSION_ERROR
 	INSERT_ERROR
+	FILE_ERROR
+	NO_SUCH_TASK
+	UNKNOWN_COMMAND
+	PROMPT_ERROR
+	NO_SUCH_HOOK
+	ALIAS_ERROR
+	NO_SUCH_VIEW
 )
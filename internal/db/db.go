@@ -13,7 +13,49 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-type Tx = sqlx.Tx
+// Transaction, which can be nested into an outer one. Nested transaction
+// shares the outer one, committing and rolling back is left to its owner.
+type Tx struct {
+	*sqlx.Tx
+	outer    *Tx
+	finished []func(committed bool)
+}
+
+// Call the function once the outermost transaction is committed or rolled
+// back, e.g. to apply file changes only together with the database ones.
+func (tx *Tx) On_Finish(fn func(committed bool)) {
+	if tx.outer != nil {
+		tx.outer.On_Finish(fn)
+		return
+	}
+	tx.finished = append(tx.finished, fn)
+}
+
+func (tx *Tx) finish(committed bool) {
+	finished := tx.finished
+	tx.finished = nil
+	for _, fn := range finished {
+		fn(committed)
+	}
+}
+
+func (tx *Tx) Commit() error {
+	if tx.outer != nil {
+		return nil
+	}
+	er := tx.Tx.Commit()
+	tx.finish(er == nil)
+	return er
+}
+
+func (tx *Tx) Rollback() error {
+	if tx.outer != nil {
+		return nil
+	}
+	er := tx.Tx.Rollback()
+	tx.finish(false)
+	return er
+}
 
 var connection *sqlx.DB
 
@@ -47,7 +89,16 @@ func get_version(tx *Tx) (int, int) {
 // **Always** call `defer tx.Rollback()`. If you commit, then rollback, it
 // won't hurt in anyway.
 func Begin() *Tx {
-	return connection.MustBegin()
+	return &Tx{Tx: connection.MustBegin()}
+}
+
+// Begin a transaction nested into the outer one, or a new one, if there is no
+// outer transaction.
+func Begin_In(outer *Tx) *Tx {
+	if outer == nil {
+		return Begin()
+	}
+	return &Tx{Tx: outer.Tx, outer: outer}
 }
 
 func getSortedMigrations() ([]string, int) {
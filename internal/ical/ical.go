@@ -0,0 +1,272 @@
+// Minimal iCalendar (RFC 5545) support: components with properties, line
+// folding and text escaping. Values are kept as raw strings, so the caller
+// decides which of them are text and should be escaped.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Lines should not be longer than 75 octets, excluding the line break.
+const MAX_LINE_OCTETS = 75
+
+const DATE_LAYOUT = "20060102"
+const DATE_TIME_LAYOUT = "20060102T150405Z"
+
+type Param struct {
+	Name  string
+	Value string
+}
+
+type Property struct {
+	Name   string
+	Params []Param
+	Value  string
+}
+
+type Component struct {
+	Name       string
+	Properties []*Property
+	Components []*Component
+}
+
+func New_Component(name string) *Component {
+	return &Component{Name: name}
+}
+
+func (c *Component) Add(name string, value string, params ...Param) *Component {
+	c.Properties = append(c.Properties, &Property{Name: name, Params: params, Value: value})
+	return c
+}
+
+// Add property with the value escaped as text.
+func (c *Component) Add_Text(name string, value string, params ...Param) *Component {
+	return c.Add(name, Escape_Text(value), params...)
+}
+
+func (c *Component) Get(name string) *Property {
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+func (p *Property) Get_Param(name string) string {
+	for _, param := range p.Params {
+		if param.Name == name {
+			return param.Value
+		}
+	}
+	return ""
+}
+
+func Escape_Text(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, ";", "\\;")
+	s = strings.ReplaceAll(s, ",", "\\,")
+	s = strings.ReplaceAll(s, "\r\n", "\\n")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return s
+}
+
+func Unescape_Text(s string) string {
+	var b strings.Builder
+	escaped := false
+	for _, c := range s {
+		if !escaped {
+			if c == '\\' {
+				escaped = true
+			} else {
+				b.WriteRune(c)
+			}
+			continue
+		}
+		escaped = false
+		switch c {
+		case 'n', 'N':
+			b.WriteRune('\n')
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// Split the line into chunks of at most 75 octets, never in the middle of a
+// UTF-8 sequence. Continuation chunks are prefixed by a space, which counts
+// towards the limit.
+func Fold(line string) string {
+	var b strings.Builder
+	limit := MAX_LINE_OCTETS
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = MAX_LINE_OCTETS - 1
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+func format_param_value(value string) string {
+	if strings.ContainsAny(value, ";:,") {
+		return "\"" + value + "\""
+	}
+	return value
+}
+
+func (p *Property) String() string {
+	var b strings.Builder
+	b.WriteString(p.Name)
+	for _, param := range p.Params {
+		b.WriteString(";" + param.Name + "=" + format_param_value(param.Value))
+	}
+	b.WriteString(":" + p.Value)
+	return b.String()
+}
+
+func Encode(w io.Writer, c *Component) error {
+	lines := []string{"BEGIN:" + c.Name}
+	for _, p := range c.Properties {
+		lines = append(lines, p.String())
+	}
+	for _, line := range lines {
+		_, er := io.WriteString(w, Fold(line)+"\r\n")
+		if er != nil {
+			return er
+		}
+	}
+	for _, sub := range c.Components {
+		er := Encode(w, sub)
+		if er != nil {
+			return er
+		}
+	}
+	_, er := io.WriteString(w, "END:"+c.Name+"\r\n")
+	return er
+}
+
+// Read logical lines, joining the folded ones.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	lines := []string{}
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') {
+			if len(lines) == 0 {
+				return nil, fmt.Errorf("continuation without a line to continue")
+			}
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parse_property(line string) (*Property, error) {
+	p := &Property{}
+	i := strings.IndexAny(line, ";:")
+	if i < 0 {
+		return nil, fmt.Errorf("missing value of '%s'", line)
+	}
+	if i == 0 {
+		return nil, fmt.Errorf("missing property name")
+	}
+	p.Name = strings.ToUpper(line[:i])
+	rest := line[i:]
+	for rest[0] == ';' {
+		rest = rest[1:]
+		eq := strings.Index(rest, "=")
+		if eq <= 0 {
+			return nil, fmt.Errorf("malformed parameter of '%s'", p.Name)
+		}
+		param := Param{Name: strings.ToUpper(rest[:eq])}
+		rest = rest[eq+1:]
+		if strings.HasPrefix(rest, "\"") {
+			end := strings.Index(rest[1:], "\"")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted parameter of '%s'", p.Name)
+			}
+			param.Value = rest[1 : end+1]
+			rest = rest[end+2:]
+		} else {
+			end := strings.IndexAny(rest, ";:")
+			if end < 0 {
+				return nil, fmt.Errorf("missing value of '%s'", p.Name)
+			}
+			param.Value = rest[:end]
+			rest = rest[end:]
+		}
+		p.Params = append(p.Params, param)
+		if rest == "" {
+			return nil, fmt.Errorf("missing value of '%s'", p.Name)
+		}
+	}
+	if rest[0] != ':' {
+		return nil, fmt.Errorf("missing value of '%s'", p.Name)
+	}
+	p.Value = rest[1:]
+	return p, nil
+}
+
+// Parse the single top-level component, usually `VCALENDAR`.
+func Decode(r io.Reader) (*Component, error) {
+	lines, er := unfold(r)
+	if er != nil {
+		return nil, er
+	}
+
+	var root *Component
+	stack := []*Component{}
+	for i, line := range lines {
+		p, er := parse_property(line)
+		if er != nil {
+			return nil, fmt.Errorf("content line %d: %s", i+1, er)
+		}
+		switch p.Name {
+		case "BEGIN":
+			c := New_Component(strings.ToUpper(p.Value))
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, c)
+			} else if root != nil {
+				return nil, fmt.Errorf("content line %d: more than one top-level component", i+1)
+			} else {
+				root = c
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return nil, fmt.Errorf("content line %d: unexpected END:%s", i+1, p.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("content line %d: property outside of a component", i+1)
+			}
+			c := stack[len(stack)-1]
+			c.Properties = append(c.Properties, p)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unterminated component '%s'", stack[len(stack)-1].Name)
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no component found")
+	}
+	return root, nil
+}
@@ -0,0 +1,57 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_escape_round_trip_ok(t *testing.T) {
+	text := "Buy milk, eggs; bread\\butter\nand call back"
+	assert.Equal(t, "Buy milk\\, eggs\\; bread\\\\butter\\nand call back", Escape_Text(text))
+	assert.Equal(t, text, Unescape_Text(Escape_Text(text)))
+}
+
+func Test_fold_ok(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("Задача ✓ ", 20)
+	folded := Fold(line)
+	for _, l := range strings.Split(folded, "\r\n") {
+		assert.LessOrEqual(t, len(l), MAX_LINE_OCTETS)
+		assert.True(t, strings.ToValidUTF8(l, "?") == l)
+	}
+	assert.Equal(t, line, strings.ReplaceAll(folded, "\r\n ", ""))
+}
+
+func Test_encode_decode_ok(t *testing.T) {
+	summary := strings.Repeat("Long, long; title ", 10)
+	calendar := New_Component("VCALENDAR").Add("VERSION", "2.0")
+	calendar.Components = append(
+		calendar.Components,
+		New_Component("VTODO").
+			Add("UID", "1@tasker").
+			Add_Text("SUMMARY", summary).
+			Add("DUE", "20260501", Param{Name: "VALUE", Value: "DATE"}).
+			Add("X-NOTE", "a", Param{Name: "X-PLACE", Value: "Home: room 1"}),
+	)
+
+	var b strings.Builder
+	er := Encode(&b, calendar)
+	assert.Nil(t, er)
+
+	decoded, er := Decode(strings.NewReader(b.String()))
+	assert.Nil(t, er)
+	assert.Equal(t, calendar, decoded)
+	todo := decoded.Components[0]
+	assert.Equal(t, summary, Unescape_Text(todo.Get("SUMMARY").Value))
+	assert.Equal(t, "DATE", todo.Get("DUE").Get_Param("VALUE"))
+	assert.Equal(t, "Home: room 1", todo.Get("X-NOTE").Get_Param("X-PLACE"))
+}
+
+func Test_decode_error(t *testing.T) {
+	_, er := Decode(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VCALENDAR\r\n"))
+	assert.EqualError(t, er, "content line 3: unexpected END:VCALENDAR")
+
+	_, er = Decode(strings.NewReader("BEGIN:VCALENDAR\r\nSUMMARY\r\n"))
+	assert.EqualError(t, er, "content line 2: missing value of 'SUMMARY'")
+}
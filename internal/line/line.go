@@ -0,0 +1,450 @@
+// Line editor for the interactive input: cursor movement, history with
+// reverse search and completion. If the input is not a terminal, or the
+// terminal cannot be switched to the raw mode, lines are read as is.
+//
+// Keys:
+//   - Left/Right, Ctrl-B/Ctrl-F: move the cursor
+//   - Home/End, Ctrl-A/Ctrl-E: move to the start or to the end of the line
+//   - Up/Down, Ctrl-P/Ctrl-N: previous or next history entry
+//   - Backspace, Delete: delete the character before or under the cursor
+//   - Ctrl-U, Ctrl-K: delete everything before or after the cursor
+//   - Ctrl-W: delete the word before the cursor
+//   - Ctrl-R: reverse history search, Ctrl-R again for the older match, Enter
+//     to submit, Ctrl-G or Escape to cancel, any other key to edit the match
+//   - Tab: complete the word before the cursor
+//   - Ctrl-L: clear the screen
+//   - Ctrl-C: discard the line
+//   - Ctrl-D: end of input on the empty line, otherwise Delete
+package line
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"tasker/internal/term"
+	"unicode"
+)
+
+// History file keeps only the last entries.
+const MAX_HISTORY = 1000
+
+const (
+	KEY_CTRL_A    = "\x01"
+	KEY_CTRL_B    = "\x02"
+	KEY_CTRL_C    = "\x03"
+	KEY_CTRL_D    = "\x04"
+	KEY_CTRL_E    = "\x05"
+	KEY_CTRL_F    = "\x06"
+	KEY_CTRL_G    = "\x07"
+	KEY_CTRL_H    = "\x08"
+	KEY_TAB       = "\t"
+	KEY_LF        = "\n"
+	KEY_CTRL_K    = "\x0b"
+	KEY_CTRL_L    = "\x0c"
+	KEY_CR        = "\r"
+	KEY_CTRL_N    = "\x0e"
+	KEY_CTRL_P    = "\x10"
+	KEY_CTRL_R    = "\x12"
+	KEY_CTRL_U    = "\x15"
+	KEY_CTRL_W    = "\x17"
+	KEY_ESCAPE    = "\x1b"
+	KEY_BACKSPACE = "\x7f"
+)
+
+var KEYS_UP = []string{"\x1b[A", "\x1bOA", KEY_CTRL_P}
+var KEYS_DOWN = []string{"\x1b[B", "\x1bOB", KEY_CTRL_N}
+var KEYS_RIGHT = []string{"\x1b[C", "\x1bOC", KEY_CTRL_F}
+var KEYS_LEFT = []string{"\x1b[D", "\x1bOD", KEY_CTRL_B}
+var KEYS_HOME = []string{"\x1b[H", "\x1bOH", "\x1b[1~", "\x1b[7~", KEY_CTRL_A}
+var KEYS_END = []string{"\x1b[F", "\x1bOF", "\x1b[4~", "\x1b[8~", KEY_CTRL_E}
+var KEYS_DELETE = []string{"\x1b[3~"}
+var KEYS_BACKSPACE = []string{KEY_BACKSPACE, KEY_CTRL_H}
+var KEYS_ENTER = []string{KEY_CR, KEY_LF}
+
+// Returns candidates to replace the input from the byte offset `start` up to
+// the end, which is the cursor position.
+type Completer func(input string) (start int, candidates []string)
+
+type Editor struct {
+	in           *os.File
+	reader       *bufio.Reader
+	out          io.Writer
+	history      []string
+	history_path string
+	Complete     Completer
+
+	prompt string
+	buf    []rune
+	pos    int
+}
+
+// History is loaded from the file and appended to it, pass an empty path to
+// keep it in memory only.
+func New_Editor(in *os.File, out io.Writer, history_path string) *Editor {
+	e := &Editor{
+		in:           in,
+		reader:       bufio.NewReader(in),
+		out:          out,
+		history_path: history_path,
+	}
+	e.load_history()
+	return e
+}
+
+// Read the line without the line break. Returns `io.EOF` once the input ends.
+func (e *Editor) Read_Line(prompt string) (string, error) {
+	state, er := term.Make_Raw(e.in)
+	if er != nil {
+		fmt.Fprint(e.out, prompt)
+		line, er := e.reader.ReadString('\n')
+		if er != nil {
+			return "", er
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	line, er := e.edit(prompt, "")
+	term.Restore(e.in, state)
+	if er != nil {
+		return "", er
+	}
+	e.Add_History(line)
+	return line, nil
+}
+
+// Read a single key, while the terminal is already in the raw mode.
+func (e *Editor) Read_Key() (string, error) {
+	return e.read_key()
+}
+
+// Edit the line starting with the initial text, while the terminal is already
+// in the raw mode. The line is not added to the history.
+func (e *Editor) Edit(prompt string, initial string) (string, error) {
+	return e.edit(prompt, initial)
+}
+
+func (e *Editor) edit(prompt string, initial string) (string, error) {
+	e.prompt = prompt
+	e.set_line(initial)
+	history_index := len(e.history)
+	draft := ""
+	e.refresh()
+
+	for {
+		key, er := e.read_key()
+		if er != nil {
+			return "", er
+		}
+
+		if key == KEY_CTRL_R {
+			submit, er := e.search()
+			if er != nil {
+				return "", er
+			}
+			if !submit {
+				continue
+			}
+			key = KEY_CR
+		}
+
+		switch {
+		case in(key, KEYS_ENTER):
+			fmt.Fprint(e.out, "\r\n")
+			return string(e.buf), nil
+		case key == KEY_CTRL_C:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", nil
+		case key == KEY_CTRL_D && len(e.buf) == 0:
+			fmt.Fprint(e.out, "\r\n")
+			return "", io.EOF
+		case key == KEY_CTRL_D, in(key, KEYS_DELETE):
+			if e.pos < len(e.buf) {
+				e.buf = append(e.buf[:e.pos], e.buf[e.pos+1:]...)
+			}
+		case in(key, KEYS_BACKSPACE):
+			if e.pos > 0 {
+				e.buf = append(e.buf[:e.pos-1], e.buf[e.pos:]...)
+				e.pos--
+			}
+		case in(key, KEYS_LEFT):
+			if e.pos > 0 {
+				e.pos--
+			}
+		case in(key, KEYS_RIGHT):
+			if e.pos < len(e.buf) {
+				e.pos++
+			}
+		case in(key, KEYS_HOME):
+			e.pos = 0
+		case in(key, KEYS_END):
+			e.pos = len(e.buf)
+		case in(key, KEYS_UP):
+			if history_index > 0 {
+				if history_index == len(e.history) {
+					draft = string(e.buf)
+				}
+				history_index--
+				e.set_line(e.history[history_index])
+			}
+		case in(key, KEYS_DOWN):
+			if history_index < len(e.history) {
+				history_index++
+				if history_index == len(e.history) {
+					e.set_line(draft)
+				} else {
+					e.set_line(e.history[history_index])
+				}
+			}
+		case key == KEY_CTRL_U:
+			e.buf = e.buf[e.pos:]
+			e.pos = 0
+		case key == KEY_CTRL_K:
+			e.buf = e.buf[:e.pos]
+		case key == KEY_CTRL_W:
+			start := e.pos
+			for start > 0 && e.buf[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && e.buf[start-1] != ' ' {
+				start--
+			}
+			e.buf = append(e.buf[:start], e.buf[e.pos:]...)
+			e.pos = start
+		case key == KEY_CTRL_L:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case key == KEY_TAB:
+			e.complete()
+		default:
+			r := []rune(key)
+			if len(r) == 1 && unicode.IsPrint(r[0]) {
+				e.insert(r)
+			}
+		}
+		e.refresh()
+	}
+}
+
+// Keys are returned as strings: a single character, or a whole escape
+// sequence. Escape sequences arrive at once, so Escape followed by nothing is
+// the Escape key itself.
+func (e *Editor) read_key() (string, error) {
+	r, _, er := e.reader.ReadRune()
+	if er != nil {
+		return "", er
+	}
+	if r != '\x1b' {
+		return string(r), nil
+	}
+	if e.reader.Buffered() == 0 {
+		return KEY_ESCAPE, nil
+	}
+	next, _, er := e.reader.ReadRune()
+	if er != nil {
+		return "\x1b", nil
+	}
+	switch next {
+	case '[':
+		seq := "\x1b["
+		for {
+			c, er := e.reader.ReadByte()
+			if er != nil {
+				return seq, nil
+			}
+			seq += string(c)
+			// Final byte of the control sequence.
+			if c >= 0x40 && c <= 0x7e {
+				return seq, nil
+			}
+		}
+	case 'O':
+		c, er := e.reader.ReadByte()
+		if er != nil {
+			return "\x1bO", nil
+		}
+		return "\x1bO" + string(c), nil
+	default:
+		return "\x1b" + string(next), nil
+	}
+}
+
+func in(key string, keys []string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Editor) insert(r []rune) {
+	tail := append(r, e.buf[e.pos:]...)
+	e.buf = append(e.buf[:e.pos], tail...)
+	e.pos += len(r)
+}
+
+func (e *Editor) set_line(line string) {
+	e.buf = []rune(line)
+	e.pos = len(e.buf)
+}
+
+func (e *Editor) refresh() {
+	e.draw(e.prompt, e.buf, e.pos)
+}
+
+// The whole line is redrawn, then the cursor is moved back to its position.
+func (e *Editor) draw(prompt string, buf []rune, pos int) {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(buf))
+	if back := term.Width(string(buf[pos:])); back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+// Replace the word before the cursor with the candidate, or with the common
+// prefix of the candidates. If there is nothing to add, candidates are listed
+// under the line.
+func (e *Editor) complete() {
+	if e.Complete == nil {
+		return
+	}
+	input := string(e.buf[:e.pos])
+	start, candidates := e.Complete(input)
+	if len(candidates) == 0 || start < 0 || start > len(input) {
+		return
+	}
+	word := input[start:]
+	replacement := common_prefix(candidates)
+	if len(candidates) == 1 {
+		replacement += " "
+	}
+	if replacement != word && strings.HasPrefix(replacement, word) {
+		start_pos := len([]rune(input[:start]))
+		tail := append([]rune(replacement), e.buf[e.pos:]...)
+		e.buf = append(e.buf[:start_pos], tail...)
+		e.pos = start_pos + len([]rune(replacement))
+		return
+	}
+	if len(candidates) > 1 {
+		fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	}
+}
+
+func common_prefix(items []string) string {
+	prefix := items[0]
+	for _, item := range items[1:] {
+		for !strings.HasPrefix(item, prefix) {
+			_, size := last_rune(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
+
+func last_rune(s string) (rune, int) {
+	r := []rune(s)
+	if len(r) == 0 {
+		return 0, 0
+	}
+	last := r[len(r)-1]
+	return last, len(string(last))
+}
+
+// Returns true, if the found line should be submitted right away. Otherwise
+// the found line is left for editing.
+func (e *Editor) search() (bool, error) {
+	original := string(e.buf)
+	query := []rune{}
+	match := len(e.history)
+	found := ""
+
+	for {
+		label := fmt.Sprintf("(reverse-i-search)`%s': ", string(query))
+		e.draw(label, []rune(found), len([]rune(found)))
+
+		key, er := e.read_key()
+		if er != nil {
+			return false, er
+		}
+		switch {
+		case key == KEY_CTRL_R:
+			match, found = e.find_history(string(query), match-1, match, found)
+		case in(key, KEYS_BACKSPACE):
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+			}
+			match, found = e.find_history(string(query), len(e.history)-1, len(e.history), "")
+		case in(key, KEYS_ENTER):
+			e.set_line(found)
+			return true, nil
+		case key == KEY_CTRL_G, key == KEY_CTRL_C, key == KEY_ESCAPE:
+			e.set_line(original)
+			return false, nil
+		default:
+			r := []rune(key)
+			if len(r) == 1 && unicode.IsPrint(r[0]) {
+				query = append(query, r...)
+				from := match
+				if from >= len(e.history) {
+					from = len(e.history) - 1
+				}
+				match, found = e.find_history(string(query), from, match, found)
+				continue
+			}
+			e.set_line(found)
+			return false, nil
+		}
+	}
+}
+
+// Search the history backwards, starting at the index. If nothing is found,
+// the current match is kept.
+func (e *Editor) find_history(query string, from int, match int, found string) (int, string) {
+	for i := from; i >= 0; i-- {
+		if strings.Contains(e.history[i], query) {
+			return i, e.history[i]
+		}
+	}
+	return match, found
+}
+
+func (e *Editor) load_history() {
+	if e.history_path == "" {
+		return
+	}
+	body, er := os.ReadFile(e.history_path)
+	if er != nil {
+		return
+	}
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line != "" {
+			e.history = append(e.history, line)
+		}
+	}
+	if len(e.history) > MAX_HISTORY {
+		e.history = e.history[len(e.history)-MAX_HISTORY:]
+		os.WriteFile(e.history_path, []byte(strings.Join(e.history, "\n")+"\n"), 0600)
+	}
+}
+
+// Empty lines and repeats of the last entry are skipped. History is kept on a
+// best effort basis, so failed writes are ignored.
+func (e *Editor) Add_History(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > MAX_HISTORY {
+		e.history = e.history[1:]
+	}
+	if e.history_path == "" {
+		return
+	}
+	f, er := os.OpenFile(e.history_path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if er != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
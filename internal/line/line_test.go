@@ -0,0 +1,111 @@
+package line
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func new_test_editor(keys string, history ...string) *Editor {
+	return &Editor{
+		reader:  bufio.NewReader(strings.NewReader(keys)),
+		out:     &bytes.Buffer{},
+		history: history,
+	}
+}
+
+func Test_edit_ok(t *testing.T) {
+	e := new_test_editor("abc\x1b[D\x1b[DX\x01Y\x05Z\x1b[3~\x7f\r")
+	line, er := e.edit("> ", "")
+	assert.Nil(t, er)
+	assert.Equal(t, "YaXbc", line)
+
+	e = new_test_editor("one two three\x17\x17four\x01\x0b\x03")
+	line, er = e.edit("> ", "")
+	assert.Nil(t, er)
+	assert.Equal(t, "", line)
+
+	e = new_test_editor("задача\x1b[D\x15\r")
+	line, er = e.edit("> ", "")
+	assert.Nil(t, er)
+	assert.Equal(t, "а", line)
+}
+
+func Test_edit_eof_error(t *testing.T) {
+	_, er := new_test_editor("\x04").edit("> ", "")
+	assert.Equal(t, io.EOF, er)
+	_, er = new_test_editor("abc").edit("> ", "")
+	assert.Equal(t, io.EOF, er)
+}
+
+func Test_edit_history_ok(t *testing.T) {
+	e := new_test_editor("draft\x1b[A\x1b[A\x1b[B!\r", "one", "two")
+	line, _ := e.edit("> ", "")
+	assert.Equal(t, "two!", line)
+
+	e = new_test_editor("draft\x1b[A\x1b[B\r", "one")
+	line, _ = e.edit("> ", "")
+	assert.Equal(t, "draft", line)
+}
+
+func Test_edit_search_ok(t *testing.T) {
+	e := new_test_editor("\x12wo\r", "s -c", "w work", "s -a")
+	line, _ := e.edit("> ", "")
+	assert.Equal(t, "w work", line)
+
+	e = new_test_editor("\x12s\x12\x05!\r", "s -c", "w work", "s -a")
+	line, _ = e.edit("> ", "")
+	assert.Equal(t, "s -c!", line)
+
+	e = new_test_editor("draft\x12s\x07\r", "s -c")
+	line, _ = e.edit("> ", "")
+	assert.Equal(t, "draft", line)
+}
+
+func Test_edit_complete_ok(t *testing.T) {
+	complete := func(input string) (int, []string) {
+		start := strings.LastIndex(input, " ") + 1
+		candidates := []string{}
+		for _, c := range []string{"show", "sync", "switch"} {
+			if strings.HasPrefix(c, input[start:]) {
+				candidates = append(candidates, c)
+			}
+		}
+		return start, candidates
+	}
+
+	e := new_test_editor("h sy\t\r")
+	e.Complete = complete
+	line, _ := e.edit("> ", "")
+	assert.Equal(t, "h sync ", line)
+
+	e = new_test_editor("1\x01sw\t\r")
+	e.Complete = complete
+	line, _ = e.edit("> ", "")
+	assert.Equal(t, "switch 1", line)
+}
+
+func Test_history_file_ok(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	e := New_Editor(os.Stdin, io.Discard, path)
+	e.Add_History("s -c")
+	e.Add_History("s -c")
+	e.Add_History(" ")
+	e.Add_History("w work")
+
+	e = New_Editor(os.Stdin, io.Discard, path)
+	assert.Equal(t, []string{"s -c", "w work"}, e.history)
+}
+
+func Test_edit_initial_ok(t *testing.T) {
+	e := new_test_editor("\x01new \r")
+	line, _ := e.Edit("Title: ", "old")
+	assert.Equal(t, "new old", line)
+	assert.Empty(t, e.history)
+}
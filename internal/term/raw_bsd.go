@@ -0,0 +1,8 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package term
+
+import "golang.org/x/sys/unix"
+
+const IOCTL_GET_TERMIOS = unix.TIOCGETA
+const IOCTL_SET_TERMIOS = unix.TIOCSETA
@@ -0,0 +1,6 @@
+package term
+
+import "golang.org/x/sys/unix"
+
+const IOCTL_GET_TERMIOS = unix.TCGETS
+const IOCTL_SET_TERMIOS = unix.TCSETS
@@ -0,0 +1,22 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package term
+
+import (
+	"errors"
+	"os"
+)
+
+type State struct{}
+
+func Make_Raw(f *os.File) (*State, error) {
+	return nil, errors.New("raw terminal mode is not supported")
+}
+
+func Restore(f *os.File, state *State) error {
+	return nil
+}
+
+func Size(f *os.File) (int, int, error) {
+	return 0, 0, errors.New("terminal size is not supported")
+}
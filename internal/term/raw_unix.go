@@ -0,0 +1,49 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package term
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+type State struct {
+	termios unix.Termios
+}
+
+// Same as cfmakeraw(3), but the output processing is kept, so printed line
+// breaks still return the carriage.
+func Make_Raw(f *os.File) (*State, error) {
+	fd := int(f.Fd())
+	termios, er := unix.IoctlGetTermios(fd, IOCTL_GET_TERMIOS)
+	if er != nil {
+		return nil, er
+	}
+	state := &State{termios: *termios}
+
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	er = unix.IoctlSetTermios(fd, IOCTL_SET_TERMIOS, termios)
+	if er != nil {
+		return nil, er
+	}
+	return state, nil
+}
+
+func Restore(f *os.File, state *State) error {
+	return unix.IoctlSetTermios(int(f.Fd()), IOCTL_SET_TERMIOS, &state.termios)
+}
+
+// Returns columns and rows of the terminal.
+func Size(f *os.File) (int, int, error) {
+	size, er := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+	if er != nil {
+		return 0, 0, er
+	}
+	return int(size.Col), int(size.Row), nil
+}
@@ -0,0 +1,42 @@
+package term
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+type State struct {
+	mode uint32
+}
+
+// Keys are read as VT sequences, the same as on unix terminals.
+func Make_Raw(f *os.File) (*State, error) {
+	handle := windows.Handle(f.Fd())
+	var mode uint32
+	er := windows.GetConsoleMode(handle, &mode)
+	if er != nil {
+		return nil, er
+	}
+	raw := mode &^ (windows.ENABLE_ECHO_INPUT | windows.ENABLE_PROCESSED_INPUT | windows.ENABLE_LINE_INPUT)
+	raw |= windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	er = windows.SetConsoleMode(handle, raw)
+	if er != nil {
+		return nil, er
+	}
+	return &State{mode: mode}, nil
+}
+
+func Restore(f *os.File, state *State) error {
+	return windows.SetConsoleMode(windows.Handle(f.Fd()), state.mode)
+}
+
+// Returns columns and rows of the visible console window.
+func Size(f *os.File) (int, int, error) {
+	var info windows.ConsoleScreenBufferInfo
+	er := windows.GetConsoleScreenBufferInfo(windows.Handle(f.Fd()), &info)
+	if er != nil {
+		return 0, 0, er
+	}
+	return int(info.Window.Right-info.Window.Left) + 1, int(info.Window.Bottom-info.Window.Top) + 1, nil
+}
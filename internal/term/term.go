@@ -0,0 +1,169 @@
+// Terminal handling shared by the interactive modes: raw mode, size and the
+// display width of the text.
+package term
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Wide characters take two terminal cells: CJK, fullwidth forms and emoji.
+var WIDE_RANGES = [][2]rune{
+	{0x1100, 0x115f},
+	{0x2e80, 0x303e},
+	{0x3041, 0x33ff},
+	{0x3400, 0x4dbf},
+	{0x4e00, 0x9fff},
+	{0xa000, 0xa4cf},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe30, 0xfe4f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x1f300, 0x1f64f},
+	{0x1f680, 0x1f6ff},
+	{0x1f7e0, 0x1f7eb},
+	{0x1f900, 0x1f9ff},
+	{0x20000, 0x3fffd},
+}
+
+// Marks the cut of the truncated text.
+var Ellipsis = "…"
+
+// Used when the size cannot be retrieved.
+const DEFAULT_COLUMNS = 80
+const DEFAULT_ROWS = 24
+
+func Rune_Width(r rune) int {
+	if r < 0x20 || (r >= 0x7f && r < 0xa0) {
+		return 0
+	}
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	for _, wide := range WIDE_RANGES {
+		if r >= wide[0] && r <= wide[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+// Length of the escape sequence at the start of the string, zero if there is
+// none.
+func escape_length(s string) int {
+	if !strings.HasPrefix(s, "\x1b[") {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i] >= 0x40 && s[i] <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// Display width of the string, escape sequences are not counted.
+func Width(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		if n := escape_length(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		width += Rune_Width(r)
+		i += size
+	}
+	return width
+}
+
+// Cut the string to fit the width, marking the cut by the ellipsis. Escape
+// sequences are kept, and reset at the end, if the string is cut.
+func Truncate(s string, width int) string {
+	if Width(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	ellipsis := fit_ellipsis(width)
+	var b strings.Builder
+	used := 0
+	escaped := false
+	for i := 0; i < len(s); {
+		if n := escape_length(s[i:]); n > 0 {
+			b.WriteString(s[i : i+n])
+			escaped = true
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		w := Rune_Width(r)
+		if used+w > width-Width(ellipsis) {
+			break
+		}
+		b.WriteString(s[i : i+size])
+		used += w
+		i += size
+	}
+	b.WriteString(ellipsis)
+	if escaped {
+		b.WriteString("\x1b[0m")
+	}
+	return b.String()
+}
+
+// Ellipsis cut to the width, if the width is too narrow for it.
+func fit_ellipsis(width int) string {
+	if Width(Ellipsis) <= width {
+		return Ellipsis
+	}
+	return Ellipsis[:width]
+}
+
+// Pad the string by spaces up to the width, cutting it if it's wider.
+func Pad(s string, width int) string {
+	s = Truncate(s, width)
+	return s + strings.Repeat(" ", width-Width(s))
+}
+
+// Wrap the words to lines of the width, splitting the words wider than it.
+// Escape sequences are not expected.
+func Wrap(s string, width int) []string {
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(s) {
+		for Width(word) > width && width > 0 {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			head, used := "", 0
+			for _, r := range word {
+				if used+Rune_Width(r) > width && used > 0 {
+					break
+				}
+				head += string(r)
+				used += Rune_Width(r)
+			}
+			lines = append(lines, head)
+			word = word[len(head):]
+		}
+		switch {
+		case word == "":
+		case line == "":
+			line = word
+		case Width(line)+1+Width(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
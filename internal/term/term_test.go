@@ -0,0 +1,46 @@
+package term
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_width_ok(t *testing.T) {
+	assert.Equal(t, 4, Width("task"))
+	assert.Equal(t, 6, Width("задача"))
+	assert.Equal(t, 4, Width("任务"))
+	assert.Equal(t, 4, Width("🔴 a"))
+	assert.Equal(t, 1, Width("\033[32m+\033[0m"))
+	assert.Equal(t, 1, Width("é"))
+	// Invalid bytes take a cell each.
+	assert.Equal(t, 3, Width("a\xffb"))
+}
+
+func Test_truncate_ok(t *testing.T) {
+	assert.Equal(t, "task", Truncate("task", 4))
+	assert.Equal(t, "ta…", Truncate("task", 3))
+	assert.Equal(t, "任…", Truncate("任务任务", 4))
+	assert.Equal(t, "\033[32m+…\033[0m", Truncate("\033[32m+++\033[0m", 2))
+	assert.Equal(t, "a\xff…", Truncate("a\xffbcd", 3))
+	assert.Equal(t, "", Truncate("task", 0))
+}
+
+func Test_truncate_ascii_ok(t *testing.T) {
+	Ellipsis = "..."
+	defer func() { Ellipsis = "…" }()
+	assert.Equal(t, "ta...", Truncate("tasker", 5))
+	assert.Equal(t, "..", Truncate("tasker", 2))
+}
+
+func Test_pad_ok(t *testing.T) {
+	assert.Equal(t, "任务  ", Pad("任务", 6))
+	assert.Equal(t, "ta…", Pad("task", 3))
+}
+
+func Test_wrap_ok(t *testing.T) {
+	assert.Equal(t, []string{"buy some", "milk"}, Wrap("buy some milk", 8))
+	assert.Equal(t, []string{"a", "abcde", "fgh b"}, Wrap("a abcdefgh b", 5))
+	assert.Equal(t, []string{"任务", "任务"}, Wrap("任务任务", 5))
+	assert.Equal(t, []string{""}, Wrap("  ", 5))
+}
@@ -1,14 +1,18 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"tasker/internal/bone"
 	"tasker/internal/common"
 	"tasker/internal/db"
+	"tasker/internal/line"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -16,8 +20,8 @@ import (
 type handler func(ctx *Command_Context) int
 
 type Project struct {
-	Id    int    `db:"id"`
-	Title string `db:"title"`
+	Id    int    `db:"id" json:"id"`
+	Title string `db:"title" json:"title"`
 }
 
 type Task struct {
@@ -30,29 +34,32 @@ type Task struct {
 	Priority           int     `db:"completion_priority"`
 	Schedule           *string `db:"schedule"`
 	Project_Id         int     `db:"project_id"`
+	Extra              *string `db:"extra"`
+	Effort_Sec         *int    `db:"effort_sec"`
 }
 
+// Marks are set by the theme.
 func (t *Task) Get_Priority_Mark() string {
-	switch t.Priority {
-	case 1:
-		return "🟡"
-	case 2:
-		return "🔴"
-	// Everything unusual is considered as active.
-	default:
-		return "🟢"
-	}
+	return theme.Get_Priority_Mark(t)
 }
 
 func (t *Task) Get_Completion_Mark() string {
+	state := t.Get_State()
+	return bone.Paint(theme.State_Marks[state], theme.State_Colors[state])
+}
+
+// Uncolored completion mark.
+func (t *Task) Get_State_Mark() string {
+	return theme.State_Marks[t.Get_State()]
+}
+
+// Everything unusual is considered as active.
+func (t *Task) Get_State() int {
 	switch t.State {
-	case 1:
-		return "\033[32m+\033[0m"
-	case 2:
-		return "\033[31m-\033[0m"
+	case COMPLETED, REJECTED:
+		return t.State
 	default:
-		// Everything unusual is considered as active.
-		return "\033[35m.\033[0m"
+		return ACTIVE
 	}
 }
 
@@ -67,12 +74,35 @@ var COMMANDS = map[string]handler{
 	"i": info,
 	"f": find,
 	"m": move,
+	"h": help,
+	"v": view,
+
+	"format":  set_format,
+	"export":  export,
+	"import":  import_tasks,
+	"sync":    sync_markdown,
+	"review":  review,
+	"chart":   chart,
+	"heatmap": heatmap,
+	"board":   board,
+	"cal":     calendar,
+	"agenda":  agenda,
+	"next":    next,
 }
 
 type Command_Context struct {
 	Raw_Input    string
 	Command_Name string
 	Args         []string
+	// Transaction of the atomic batch, nil otherwise.
+	Tx *db.Tx
+}
+
+// Begin the command transaction. Within an atomic batch, the batch
+// transaction is joined instead, and it is committed only once all the
+// commands succeed.
+func (ctx *Command_Context) Begin() *db.Tx {
+	return db.Begin_In(ctx.Tx)
 }
 
 func (ctx *Command_Context) Has_Arg(arg string) bool {
@@ -93,6 +123,15 @@ func (ctx *Command_Context) Has_Arg_Index(arg string) (bool, int) {
 	return false, -1
 }
 
+// Get value which follows the flag, e.g. `PATH` for `-o PATH`.
+func (ctx *Command_Context) Get_Arg_Value(arg string) (string, bool) {
+	has, index := ctx.Has_Arg_Index(arg)
+	if !has || index+1 >= len(ctx.Args) {
+		return "", false
+	}
+	return ctx.Args[index+1], true
+}
+
 const (
 	SOMETIME_LATER_PRIORITY = iota
 	THIS_WEEK_PRIORITY
@@ -121,6 +160,25 @@ func clear_hooks() {
 	hooks = []any{}
 }
 
+// Get the task by its hook number, as typed by the user.
+func get_hook_task(hook_str string) (*Task, int) {
+	hook, er := strconv.Atoi(hook_str)
+	if er != nil {
+		bone.Log_Error("Hook number `%s` should be integer.", hook_str)
+		return nil, common.INPUT_ERROR
+	}
+	if hook < 1 || hook > len(hooks) {
+		bone.Log_Error("Cannot find hook #%d.", hook)
+		return nil, common.NO_SUCH_HOOK
+	}
+	task, ok := hooks[hook-1].(*Task)
+	if !ok {
+		bone.Log_Error("Hook #%d is not a task.", hook)
+		return nil, common.HOOK_TYPE_ERROR
+	}
+	return task, common.OK
+}
+
 // Show statistics of the current project.
 func info(ctx *Command_Context) int {
 	return common.OK
@@ -136,7 +194,7 @@ func sw(ctx *Command_Context) int {
 		project_name = ctx.Args[0]
 	}
 
-	tx := db.Begin()
+	tx := ctx.Begin()
 	defer tx.Rollback()
 
 	type Temp_Project struct {
@@ -162,30 +220,22 @@ func move(ctx *Command_Context) int {
 		return common.ERROR
 	}
 
-	tx := db.Begin()
+	tx := ctx.Begin()
 	defer tx.Rollback()
 
-	hook, er := strconv.Atoi(ctx.Args[0])
-	if er != nil {
-		bone.Log_Error("Task id should be integer.")
-		return common.ERROR
-	}
-
-	task, ok := hooks[hook-1].(*Task)
-	if !ok {
-		bone.Log_Error("Hook #%d is not a task.", hook+1)
-		return common.HOOK_TYPE_ERROR
+	task, e := get_hook_task(ctx.Args[0])
+	if e != common.OK {
+		return e
 	}
 
 	project_name := ctx.Args[1]
-	var project Project
-	er = tx.Get(&project, "SELECT * FROM project WHERE title = $1", project_name)
+	project, er := get_project(tx, project_name)
 	if er != nil {
 		bone.Log_Error("During project '%s' search, an error occurred: %s", project_name, er)
 		return common.ERROR
 	}
 
-	_, er = tx.Exec("UPDATE task SET project_id = $1 WHERE id = $2", project.Id, task.Id)
+	er = move_task(tx, task.Id, project.Id)
 	if er != nil {
 		bone.Log_Error("During task move, an error occurred: %s", er)
 		return common.ERROR
@@ -196,7 +246,24 @@ func move(ctx *Command_Context) int {
 		return common.COMMIT_ERROR
 	}
 
-	bone.Log("Moved task to project '%s'.", project_name)
+	emit_message("Moved task to project '%s'.", project_name)
+	return common.OK
+}
+
+// Switch the output format.
+//
+// Args:
+//   - 1: format, one of `terminal`, `plain`, `json`; the current one is shown
+//     if omitted
+func set_format(ctx *Command_Context) int {
+	if len(ctx.Args) == 0 {
+		emit_message(renderer_name)
+		return common.OK
+	}
+	if !set_renderer(ctx.Args[0]) {
+		bone.Log_Error("Unknown format '%s'.", ctx.Args[0])
+		return common.INPUT_ERROR
+	}
 	return common.OK
 }
 
@@ -207,27 +274,38 @@ func find(ctx *Command_Context) int {
 var prompted = false
 var prompted_callback func(answer bool) int = nil
 
-func answer_prompt(answer bool) {
+// Whether there is someone to answer prompts. One-shot commands cannot be
+// answered, so they fail instead of prompting.
+var interactive = true
+
+func answer_prompt(answer bool) int {
 	if !prompted {
 		bone.Log_Error("Inactive prompt")
-		return
+		return common.PROMPT_ERROR
 	}
 	prompted = false
-	e := prompted_callback(answer)
+	callback := prompted_callback
+	prompted_callback = nil
+	e := callback(answer)
 	if e != common.OK {
 		bone.Log_Error("During prompted callback, an error #%d occured", e)
 	}
-	prompted_callback = nil
+	return e
 }
 
-func prompt(text string, callback func(answer bool) int) {
+func prompt(text string, callback func(answer bool) int) int {
+	if !interactive {
+		bone.Log_Error("Cannot ask '%s' in non-interactive mode.", text)
+		return common.PROMPT_ERROR
+	}
 	if prompted {
 		bone.Log_Error("Already prompted")
-		return
+		return common.PROMPT_ERROR
 	}
 	prompted = true
 	prompted_callback = callback
-	fmt.Println(text + " [Y/N]")
+	emit(&Prompt_Result{Text: text})
+	return common.OK
 }
 
 func escape_quotes(s string) string {
@@ -248,6 +326,7 @@ func escape_quotes(s string) string {
 //   - `-n TITLE`: set title
 //   - `-np TEXT`: prepend to title
 //   - `-na TEXT`: append to title
+//   - `-e EFFORT`: set effort estimate, like `30m` or `2h`, `none` to unset
 func update(ctx *Command_Context) int {
 	var er error
 
@@ -255,25 +334,15 @@ func update(ctx *Command_Context) int {
 		return common.INPUT_ERROR
 	}
 
-	tx := db.Begin()
+	tx := ctx.Begin()
 	defer tx.Rollback()
 
 	task_ids := []int{}
 	parts := strings.Split(ctx.Args[0], "+")
 	for _, p := range parts {
-		hook_number, er := strconv.Atoi(p)
-		if er != nil {
-			bone.Log_Error("Cannot convert order number `%d` to integer", hook_number)
-			return common.INPUT_ERROR
-		}
-		if hook_number-1 >= len(hooks) {
-			bone.Log_Error("Cannot find task with hook number `%d`", hook_number)
-			return common.INPUT_ERROR
-		}
-		task, ok := hooks[hook_number-1].(*Task)
-		if !ok {
-			bone.Log_Error("Hook #%d is not a task.", hook_number)
-			return common.HOOK_TYPE_ERROR
+		task, e := get_hook_task(p)
+		if e != common.OK {
+			return e
 		}
 		task_ids = append(task_ids, task.Id)
 	}
@@ -290,7 +359,7 @@ func update(ctx *Command_Context) int {
 	if ctx.Has_Arg("-d") {
 		var delete_tasks = func(answer bool) int {
 			if answer {
-				tx := db.Begin()
+				tx := ctx.Begin()
 				defer tx.Rollback()
 				delete_query := fmt.Sprintf("DELETE FROM task WHERE %s", where_query)
 				_, er = tx.Exec(delete_query, where_args...)
@@ -303,7 +372,7 @@ func update(ctx *Command_Context) int {
 					return common.COMMIT_ERROR
 				}
 
-				fmt.Printf("Deleted\n")
+				emit_message("Deleted")
 			}
 			return common.OK
 		}
@@ -311,8 +380,7 @@ func update(ctx *Command_Context) int {
 		if len(parts) > 1 {
 			task_label = "tasks"
 		}
-		prompt(fmt.Sprintf("Delete %s %s?", task_label, strings.Join(parts, ",")), delete_tasks)
-		return common.OK
+		return prompt(fmt.Sprintf("Delete %s %s?", task_label, strings.Join(parts, ",")), delete_tasks)
 	}
 	if ctx.Has_Arg("-r") {
 		set_query = fmt.Sprintf("SET state = 2, last_rejected_sec = %d", bone.Utc())
@@ -368,6 +436,36 @@ func update(ctx *Command_Context) int {
 		set_query = fmt.Sprintf("SET title = '%s' || title", escape_quotes(title))
 	}
 
+	// Title flags take all the following args as the title, so `-e` is looked
+	// up only before them, and can't be combined with them.
+	title_index := len(ctx.Args)
+	for _, flag := range []string{"-n", "-na", "-np"} {
+		if has, index := ctx.Has_Arg_Index(flag); has && index < title_index {
+			title_index = index
+		}
+	}
+	if index := slices.Index(ctx.Args[:title_index], "-e"); index >= 0 {
+		if title_index < len(ctx.Args) {
+			bone.Log_Error("`-e` can't be combined with the title flags.")
+			return common.INPUT_ERROR
+		}
+		if index+1 >= len(ctx.Args) {
+			bone.Log_Error("-e parameter missing effort")
+			return common.INPUT_ERROR
+		}
+		effort := ctx.Args[index+1]
+		if effort == "none" {
+			set_query = "SET effort_sec = NULL"
+		} else {
+			duration, er := time.ParseDuration(effort)
+			if er != nil || duration < time.Second {
+				bone.Log_Error("Expected effort like `30m` or `2h` after `-e`, got '%s'.", effort)
+				return common.INPUT_ERROR
+			}
+			set_query = fmt.Sprintf("SET effort_sec = %d", int(duration.Seconds()))
+		}
+	}
+
 	query := fmt.Sprintf("UPDATE task %s WHERE %s", set_query, where_query)
 	_, er = tx.Exec(
 		query,
@@ -383,12 +481,12 @@ func update(ctx *Command_Context) int {
 		return common.COMMIT_ERROR
 	}
 
-	fmt.Printf("Updated\n")
+	emit_message("Updated")
 	return common.OK
 }
 
 func add(ctx *Command_Context) int {
-	tx := db.Begin()
+	tx := ctx.Begin()
 	defer tx.Rollback()
 
 	add_type := ctx.Args[0]
@@ -419,7 +517,7 @@ func add(ctx *Command_Context) int {
 	if er != nil {
 		return common.COMMIT_ERROR
 	}
-	bone.Log(final_msg)
+	emit_message(final_msg)
 	return common.OK
 }
 
@@ -429,12 +527,7 @@ func add_task(ctx *Command_Context, tx *db.Tx, start int) int {
 		title += arg + " "
 	}
 	title = strings.TrimSpace(title)
-	_, er := tx.Exec(
-		"INSERT INTO task (title, created_sec, project_id) VALUES ($1, $2, $3)",
-		title,
-		bone.Utc(),
-		current_project_id,
-	)
+	_, er := insert_task(tx, title, current_project_id)
 	if er != nil {
 		bone.Log_Error("During task creation, an error occured: %s", er)
 		return common.ERROR
@@ -443,7 +536,7 @@ func add_task(ctx *Command_Context, tx *db.Tx, start int) int {
 }
 
 func add_project(ctx *Command_Context, tx *db.Tx, start int) int {
-	_, er := tx.Exec("INSERT INTO project (title) VALUES ($1)", ctx.Args[start])
+	_, er := insert_project(tx, ctx.Args[start])
 	if er != nil {
 		bone.Log_Error("During project creation, cannot insert project with title '%s', the error is: %s", ctx.Args[0], er.Error())
 		return common.INSERT_ERROR
@@ -452,22 +545,20 @@ func add_project(ctx *Command_Context, tx *db.Tx, start int) int {
 }
 
 func complete_task_fast(ctx *Command_Context) int {
-	tx := db.Begin()
-	defer tx.Rollback()
-
-	hook, er := strconv.Atoi(ctx.Args[0])
-	if er != nil {
-		bone.Log_Error("Task id should be integer.")
-		return common.ERROR
+	if len(ctx.Args) == 0 {
+		bone.Log_Error("Expected task hook number.")
+		return common.INPUT_ERROR
 	}
 
-	task, ok := hooks[hook-1].(*Task)
-	if !ok {
-		bone.Log_Error("Hook #%d is not a task.", hook+1)
-		return common.HOOK_TYPE_ERROR
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	task, e := get_hook_task(ctx.Args[0])
+	if e != common.OK {
+		return e
 	}
 
-	_, er = tx.Exec("UPDATE task SET last_completed_sec = $2, state = $3 WHERE id = $1", task.Id, bone.Utc(), COMPLETED)
+	er := set_task_state(tx, task.Id, COMPLETED)
 	if er != nil {
 		bone.Log_Error("During task completion, an error occured: %s", er)
 		return common.ERROR
@@ -479,12 +570,12 @@ func complete_task_fast(ctx *Command_Context) int {
 		return common.ERROR
 	}
 
-	bone.Log("Completed task.")
+	emit_message("Completed task.")
 	return common.OK
 }
 
 func add_task_fast(ctx *Command_Context) int {
-	tx := db.Begin()
+	tx := ctx.Begin()
 	defer tx.Rollback()
 	e := add_task(ctx, tx, 0)
 	if e > 0 {
@@ -495,27 +586,25 @@ func add_task_fast(ctx *Command_Context) int {
 		bone.Log_Error("During commit, an error occured: %s", er)
 		return common.ERROR
 	}
-	bone.Log("Task created.")
+	emit_message("Task created.")
 	return common.OK
 }
 
 func reject_task_fast(ctx *Command_Context) int {
-	tx := db.Begin()
-	defer tx.Rollback()
-
-	hook, er := strconv.Atoi(ctx.Args[0])
-	if er != nil {
-		bone.Log_Error("Task id should be integer.")
-		return common.ERROR
+	if len(ctx.Args) == 0 {
+		bone.Log_Error("Expected task hook number.")
+		return common.INPUT_ERROR
 	}
 
-	task, ok := hooks[hook-1].(*Task)
-	if !ok {
-		bone.Log_Error("Hook #%d is not a task.", hook+1)
-		return common.HOOK_TYPE_ERROR
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	task, e := get_hook_task(ctx.Args[0])
+	if e != common.OK {
+		return e
 	}
 
-	_, er = tx.Exec("UPDATE task SET last_rejected_sec = $2, state = $3 WHERE id = $1", task.Id, bone.Utc(), REJECTED)
+	er := set_task_state(tx, task.Id, REJECTED)
 	if er != nil {
 		bone.Log_Error("During task rejection, an error occured: %s", er)
 		return common.ERROR
@@ -527,7 +616,7 @@ func reject_task_fast(ctx *Command_Context) int {
 		return common.ERROR
 	}
 
-	bone.Log("Rejected task.")
+	emit_message("Rejected task.")
 	return common.OK
 }
 
@@ -536,8 +625,9 @@ func reject_task_fast(ctx *Command_Context) int {
 //
 // Default chronological order: oldest first.
 //
-// Args:
-//   - `-p`: show projects instead of tasks
+// Args are the query, see query.go, e.g. `prio>=1 sched<+7d sort:-prio`.
+// Besides the terms:
+//   - `p`: show projects instead of tasks
 //   - `-reverse`: reverse order
 //   - `-a`: show all
 //   - `-c`: show only completed
@@ -547,109 +637,69 @@ func reject_task_fast(ctx *Command_Context) int {
 //   - `-srejected`: show rejection times
 //   - `-ocompleted`: order by completion time, integrates with `-reverse`
 //   - `-orejected`: order by rejection time, integrates with `-reverse`
+//   - `-limit N`, `-offset N`: show the page of the tasks
+//
+// Output taller than the terminal is piped to `$PAGER`.
 func show(ctx *Command_Context) int {
 	project_show := len(ctx.Args) > 0 && (ctx.Args[0] == "p" || ctx.Args[0] == "project")
+	if project_show {
+		return show_projects(ctx)
+	}
+	return show_tasks(ctx, []int{current_project_id})
+}
 
-	query := ""
-	where_query := ""
-	order_query := ""
-
-	if !project_show {
-		where_query = "WHERE state = 0"
-		if ctx.Has_Arg("-c") {
-			where_query = "WHERE state = 1"
-		}
-		if ctx.Has_Arg("-r") {
-			where_query = "WHERE state = 2"
-		}
-		where_query += fmt.Sprintf(" AND project_id = %d", current_project_id)
-
-		order_query = "ORDER BY created_sec ASC"
-		if ctx.Has_Arg("-reverse") {
-			order_query = "ORDER BY created_sec DESC"
-		}
-
-		if ctx.Has_Arg("-ocompleted") {
-			order_query = "ORDER BY last_completed_sec ASC"
-			if ctx.Has_Arg("-reverse") {
-				order_query = "ORDER BY last_completed_sec DESC"
-			}
-		}
-		if ctx.Has_Arg("-orejected") {
-			order_query = "ORDER BY last_rejected_sec ASC"
-			if ctx.Has_Arg("-reverse") {
-				order_query = "ORDER BY last_rejected_sec DESC"
-			}
-		}
+func show_projects(ctx *Command_Context) int {
+	tx := ctx.Begin()
+	defer tx.Rollback()
 
-		if ctx.Has_Arg("-a") {
-			where_query = ""
-			// Show active first, completed second, rejected last
-			order_query = "ORDER BY state DESC, created_sec ASC"
-			if ctx.Has_Arg("-reverse") {
-				order_query = "ORDER BY state ASC, created_sec DESC"
-			}
-		}
+	targets := []*Project{}
+	er := tx.Select(&targets, "SELECT * from project")
+	if er != nil {
+		bone.Log_Error("During project selection, an error occured: %s", er)
+		return common.ERROR
 	}
+	set_hooks(targets)
+	emit(&Project_List_Result{Projects: targets})
+	return common.OK
+}
 
-	query = "SELECT * FROM task"
-	if project_show {
-		query = "SELECT * from project"
+// Show tasks of the projects with the `show` flags. Tasks of several projects
+// are shown along with their project titles.
+func show_tasks(ctx *Command_Context, project_ids []int) int {
+	filter, er := build_task_filter(ctx.Args, project_ids)
+	if er != nil {
+		log_query_error(ctx.Args, er)
+		return common.INPUT_ERROR
 	}
-	query += " %s %s"
-	query = fmt.Sprintf(query, where_query, order_query)
-	tx := db.Begin()
+	query := fmt.Sprintf("SELECT * FROM task %s %s", filter.Where, filter.Order)
+	tx := ctx.Begin()
 	defer tx.Rollback()
 
-	if !project_show {
-		targets := []*Task{}
-		er := tx.Select(&targets, query)
-		if er != nil {
-			bone.Log_Error("During task selection, an error occured: %s", er)
-			return common.ERROR
-		}
-		set_hooks(targets)
-		if len(hooks) == 0 {
-			fmt.Print("No tasks\n")
-		}
-		for i, h := range hooks {
-			t, ok := h.(*Task)
-			if !ok {
-				bone.Log_Error("Hook #%d is not a task", i+1)
-				return common.HOOK_TYPE_ERROR
-			}
-			if ctx.Has_Arg("-screated") {
-				fmt.Printf("|%d| %s |%s| %s\n", i+1, t.Get_Completion_Mark(), convert_sec_to_str(t.Created_Sec), t.Title)
-			} else if ctx.Has_Arg("-scompleted") {
-				fmt.Printf("|%d| %s |%s| %s\n", i+1, t.Get_Completion_Mark(), convert_sec_to_str(t.Last_Completed_Sec), t.Title)
-			} else if ctx.Has_Arg("-srejected") {
-				fmt.Printf("|%d| %s |%s| %s\n", i+1, t.Get_Completion_Mark(), convert_sec_to_str(t.Last_Rejected_Sec), t.Title)
-			} else {
-				fmt.Printf("|%d| %s %s\n", i+1, t.Get_Completion_Mark(), t.Title)
-			}
-		}
-	} else {
-		targets := []*Project{}
-		er := tx.Select(&targets, query)
+	targets := []*Task{}
+	er = tx.Select(&targets, query, filter.Args...)
+	if er != nil {
+		bone.Log_Error("During task selection, an error occured: %s", er)
+		return common.ERROR
+	}
+	set_hooks(targets)
+	page := filter.Page(targets)
+	time_column := TIME_COLUMN_NONE
+	if ctx.Has_Arg("-screated") {
+		time_column = TIME_COLUMN_CREATED
+	} else if ctx.Has_Arg("-scompleted") {
+		time_column = TIME_COLUMN_COMPLETED
+	} else if ctx.Has_Arg("-srejected") {
+		time_column = TIME_COLUMN_REJECTED
+	}
+	result := &Task_List_Result{Tasks: page, Time_Column: time_column, Offset: min(filter.Offset, len(targets))}
+	if len(project_ids) != 1 {
+		result.Project_Titles, er = get_project_titles(tx)
 		if er != nil {
 			bone.Log_Error("During project selection, an error occured: %s", er)
 			return common.ERROR
 		}
-		set_hooks(targets)
-		if len(hooks) == 0 {
-			// This shouldn't be possible.
-			fmt.Print("No projects?\n")
-		}
-		for i, h := range hooks {
-			t, ok := h.(*Project)
-			if !ok {
-				bone.Log_Error("Hook #%d is not a project.", i+1)
-				return common.HOOK_TYPE_ERROR
-			}
-			fmt.Printf("|%d| %s\n", i+1, t.Title)
-		}
 	}
-
+	emit(result)
 	return common.OK
 }
 
@@ -657,11 +707,19 @@ func convert_sec_to_str(sec int) string {
 	return bone.Date_Sec(sec, "2006-01-02 15:04")
 }
 
-func process_input(input string) {
+// Process the input line, which may hold several commands separated by `;`.
+// Returns the code of the first failed command.
+func process_input(input string) int {
+	return process_batch(input, nil)
+}
+
+// Call a single command, within the batch transaction, if any. Returns the code
+// of the called command.
+func process_command(input string, tx *db.Tx) int {
 	// Quoted strings are not yet supported - they will be separated as everything else.
 	input_parts := strings.Fields(input)
 	if len(input_parts) == 0 {
-		return
+		return common.OK
 	}
 
 	if prompted {
@@ -676,76 +734,135 @@ func process_input(input string) {
 		case "N":
 			answer = false
 		default:
-			bone.Log("Type answer 'Y' or 'N'")
-			return
+			emit_message("Type answer 'Y' or 'N'")
+			return common.INPUT_ERROR
 		}
-		answer_prompt(answer)
-		return
+		return answer_prompt(answer)
 	}
 
 	command_name := input_parts[0]
+	args := []string{}
+	if len(input_parts) > 1 {
+		args = input_parts[1:]
+	}
+
+	alias, e := find_alias(command_name)
+	if e != common.OK {
+		return e
+	}
+	if alias != nil {
+		return call_alias(alias, args, tx)
+	}
 
 	cmd, ok := COMMANDS[command_name]
 	if !ok {
 		bone.Log_Error("Unrecognized command: " + input)
-		return
-	}
-
-	args := []string{}
-	if len(input_parts) > 1 {
-		args = input_parts[1:]
+		return common.UNKNOWN_COMMAND
 	}
 	ctx := Command_Context{
 		Raw_Input:    input,
 		Command_Name: command_name,
 		Args:         args,
+		Tx:           tx,
 	}
 
-	e := cmd(&ctx)
+	e = cmd(&ctx)
 	if e > 0 {
 		bone.Log_Error("While calling a command `%s`, an error occured: %s", command_name, bone.Tr_Code(e))
-		return
 	}
+	return e
+}
+
+// Exit status of the process is the code of the failed command, or of the
+// failed mode. Codes above 125 are reserved by shells, so they are clamped.
+func exit_status(code int) int {
+	if code > 125 {
+		return 125
+	}
+	return code
 }
 
 func main() {
+	os.Exit(exit_status(run()))
+}
+
+func run() int {
+	rpc_mode := flag.Bool("rpc", false, "Speak JSON-RPC 2.0 over stdin and stdout.")
+	format := flag.String("format", "terminal", "Output format, one of `terminal`, `plain`, `json`.")
+	script_path := flag.String("script", "", "Run commands from the file, `-` for stdin.")
+	atomic := flag.Bool("atomic", false, "Run all commands of the script or of the one-shot input in one transaction.")
+	completion_shell := flag.String("completion", "", "Print the completion script for `bash`, `zsh` or `fish`.")
+	complete := flag.String(COMPLETE_FLAG, "", "Print completion candidates for the one-shot input.")
+	tui_mode := flag.Bool("tui", false, "Start the full-screen terminal UI.")
 	bone.Init("tasker")
+	if *rpc_mode {
+		// Stdout carries only the responses.
+		bone.Log_Writer = os.Stderr
+	}
 	e := db.Init()
 	if e > 0 {
 		panic("Failed to initialize db")
 	}
 	defer db.Deinit()
+	load_theme()
+	load_aliases()
 
-	// Execute one-shot command
-	if len(os.Args) > 2 && os.Args[1] == "--" {
-		input := strings.Join(os.Args[2:], " ")
-		input = strings.TrimSpace(input)
-		if input == "q" {
-			return
-		}
-		process_input(input)
-		return
+	if *completion_shell != "" {
+		return write_completion_script(os.Stdout, *completion_shell)
+	}
+	if *complete != "" {
+		write_complete_candidates(os.Stdout, *complete)
+		return common.OK
 	}
 
-	console_reader := bufio.NewReader(os.Stdin)
+	if *rpc_mode {
+		return serve_rpc(os.Stdin, os.Stdout)
+	}
+	if *tui_mode {
+		return run_tui()
+	}
+
+	args := flag.Args()
+	if len(args) > 0 && args[0] == "serve" {
+		return serve(args[1:])
+	}
+
+	if !set_renderer(*format) {
+		bone.Log_Error("Unknown format '%s'.", *format)
+		return common.INPUT_ERROR
+	}
+
+	// Execute one-shot command, everything after `--` is the input.
+	if i := slices.Index(os.Args, "--"); i >= 0 && i+1 < len(os.Args) {
+		input := strings.Join(os.Args[i+1:], " ")
+		return run_script(strings.NewReader(input), *atomic)
+	}
+
+	if *script_path != "" {
+		return run_script_file(*script_path, *atomic)
+	}
+
+	// Commands piped to tasker are run as a script.
+	if !is_terminal(os.Stdin) {
+		return run_script(os.Stdin, *atomic)
+	}
+
+	editor := line.New_Editor(os.Stdin, os.Stdout, bone.Userdir("history"))
+	editor.Complete = complete_input
 
 	// Main loop is blocking on input, other background tasks are goroutines.
 	for {
-		var final_sign = ">"
-		if prompted {
-			final_sign = "?"
-		}
-		fmt.Printf("\033[33m(%s)\033[0m\033[35m%s\033[0m ", current_project_name, final_sign)
-		input, er := console_reader.ReadString('\n')
+		input, er := editor.Read_Line(renderer.Repl_Prompt(current_project_name, prompted))
 		if er != nil {
-			if er.Error() != "EOF" {
+			if er != io.EOF {
 				bone.Log_Error("Unexpected error occured while reading console: %s", er)
+				return common.INPUT_ERROR
 			}
-			return
+			return common.OK
 		}
 		input = strings.TrimSpace(input)
 		if input == "q" {
-			return
+			return common.OK
 		}
 		process_input(input)
 	}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests share the in-memory database, configured by testing.cfg of the
+// repository root.
+func TestMain(m *testing.M) {
+	os.Setenv("DBSYNC", "1")
+	if bone.Init("tasker") > 0 {
+		panic("Failed to initialize bone")
+	}
+	if db.Init() > 0 {
+		panic("Failed to initialize db")
+	}
+	code := m.Run()
+	db.Deinit()
+	os.Exit(code)
+}
+
+// Switch to the new project, and capture the output of the renderer of the
+// kind, like `plain` or `json`. Both are restored after the test.
+func with_project_output(t *testing.T, name string, renderer_kind string) (int, *bytes.Buffer) {
+	tx := db.Begin()
+	project_id, er := insert_project(tx, name)
+	assert.Nil(t, er)
+	assert.Nil(t, tx.Commit())
+
+	previous_id, previous_name, previous_renderer := current_project_id, current_project_name, renderer
+	var output bytes.Buffer
+	current_project_id, current_project_name = project_id, name
+	renderer = RENDERERS[renderer_kind](&output)
+	t.Cleanup(func() {
+		current_project_id, current_project_name, renderer = previous_id, previous_name, previous_renderer
+	})
+	return project_id, &output
+}
+
+func Test_process_input_unknown_command_error(t *testing.T) {
+	assert.Equal(t, common.UNKNOWN_COMMAND, process_input("nosuchcommand 1"))
+}
+
+func Test_process_input_no_hook_error(t *testing.T) {
+	clear_hooks()
+	assert.Equal(t, common.NO_SUCH_HOOK, process_input("+ 1"))
+	assert.Equal(t, common.INPUT_ERROR, process_input("- x"))
+	assert.Equal(t, common.INPUT_ERROR, process_input("+"))
+}
+
+func Test_process_input_non_interactive_prompt_error(t *testing.T) {
+	interactive = false
+	defer func() { interactive = true }()
+	set_hooks([]*Task{{Id: 1}})
+	defer clear_hooks()
+
+	assert.Equal(t, common.PROMPT_ERROR, process_input("u 1 -d"))
+	assert.False(t, prompted)
+}
+
+func Test_update_title_with_effort_flag_ok(t *testing.T) {
+	tx := db.Begin()
+	project_id, er := insert_project(tx, "update_title")
+	assert.Nil(t, er)
+	task_id, er := insert_task(tx, "Search", project_id)
+	assert.Nil(t, er)
+	assert.Nil(t, tx.Commit())
+	set_hooks([]*Task{{Id: task_id}})
+	defer clear_hooks()
+
+	get_title := func() (string, *int) {
+		tx := db.Begin()
+		defer tx.Rollback()
+		task, er := get_task(tx, task_id)
+		assert.Nil(t, er)
+		return task.Title, task.Effort_Sec
+	}
+
+	assert.Equal(t, common.OK, process_input("u 1 -n use grep -e flag"))
+	title, effort := get_title()
+	assert.Equal(t, "use grep -e flag", title)
+	assert.Nil(t, effort)
+
+	assert.Equal(t, common.OK, process_input("u 1 -n note -e 5m"))
+	title, effort = get_title()
+	assert.Equal(t, "note -e 5m", title)
+	assert.Nil(t, effort)
+
+	assert.Equal(t, common.INPUT_ERROR, process_input("u 1 -e 5m -n note"))
+	assert.Equal(t, common.INPUT_ERROR, process_input("u 1 -e"))
+}
+
+func Test_exit_status_ok(t *testing.T) {
+	assert.Equal(t, 0, exit_status(common.OK))
+	assert.Equal(t, common.NO_SUCH_PROJECT, exit_status(common.NO_SUCH_PROJECT))
+	assert.Equal(t, 125, exit_status(1000))
+}
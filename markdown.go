@@ -0,0 +1,459 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+)
+
+// Two-way sync of a project with a Markdown checklist file.
+//
+// Each checklist item is bound to the task by the hidden comment at the end of
+// the line, e.g. `- [ ] Buy milk <!-- tasker:12 -->`, so the items can be
+// freely reordered. The state of every bound task as of the last sync is kept
+// in the database: a side whose title or checkbox differs from it has been
+// changed. If both sides have changed differently, the user is prompted which
+// one to keep.
+//
+// Rules:
+//   - new item: creates the task
+//   - new active task: appends the item
+//   - checked item: completes the task, unchecked one reactivates it
+//   - removed item: rejects the active task
+//   - rejected or deleted task: removes the item
+
+var MARKDOWN_ITEM_REGEX = regexp.MustCompile(`^(\s*[-*+] \[)([ xX])\] (.*?)\s*(?:<!-- tasker:(\d+) -->)?\s*$`)
+
+type Markdown_Item struct {
+	Prefix  string
+	Checked bool
+	Title   string
+	Task_Id int
+	Removed bool
+}
+
+func (item *Markdown_Item) String() string {
+	mark := " "
+	if item.Checked {
+		mark = "x"
+	}
+	return fmt.Sprintf("%s%s] %s <!-- tasker:%d -->", item.Prefix, mark, item.Title, item.Task_Id)
+}
+
+// Lines of the file, with checklist items parsed. Lines which are not items
+// are written back untouched.
+type Markdown_File struct {
+	Lines     []string
+	Items     map[int]*Markdown_Item
+	Appended  []*Markdown_Item
+	Line_Sep  string
+	Final_Sep bool
+}
+
+func parse_markdown_file(body string) *Markdown_File {
+	f := &Markdown_File{Items: map[int]*Markdown_Item{}, Line_Sep: "\n"}
+	if strings.Contains(body, "\r\n") {
+		f.Line_Sep = "\r\n"
+		body = strings.ReplaceAll(body, "\r\n", "\n")
+	}
+	f.Final_Sep = strings.HasSuffix(body, "\n") || body == ""
+	body = strings.TrimSuffix(body, "\n")
+	if body != "" {
+		f.Lines = strings.Split(body, "\n")
+	}
+
+	for i, line := range f.Lines {
+		match := MARKDOWN_ITEM_REGEX.FindStringSubmatch(line)
+		if match == nil || match[3] == "" {
+			continue
+		}
+		item := &Markdown_Item{
+			Prefix:  match[1],
+			Checked: match[2] != " ",
+			Title:   match[3],
+		}
+		if match[4] != "" {
+			item.Task_Id, _ = strconv.Atoi(match[4])
+		}
+		f.Items[i] = item
+	}
+	return f
+}
+
+func (f *Markdown_File) String() string {
+	lines := []string{}
+	for i, line := range f.Lines {
+		item, ok := f.Items[i]
+		if !ok {
+			lines = append(lines, line)
+			continue
+		}
+		if !item.Removed {
+			lines = append(lines, item.String())
+		}
+	}
+	for _, item := range f.Appended {
+		lines = append(lines, item.String())
+	}
+	body := strings.Join(lines, f.Line_Sep)
+	if f.Final_Sep || len(f.Appended) > 0 {
+		body += f.Line_Sep
+	}
+	return body
+}
+
+type Markdown_Sync_Item struct {
+	Task_Id int    `db:"task_id"`
+	Title   string `db:"title"`
+	Checked bool   `db:"checked"`
+}
+
+// Pick the value of the side which has changed since the last sync.
+func reconcile[T comparable](base T, markdown T, tasker T) (T, bool) {
+	if markdown == tasker || tasker == base {
+		return markdown, false
+	}
+	if markdown == base {
+		return tasker, false
+	}
+	return base, true
+}
+
+type Markdown_Conflict struct {
+	Item *Markdown_Item
+	Task *Task
+}
+
+// Sync the current project with the linked Markdown checklist.
+//
+// Args:
+//   - 1: path to the Markdown file to link with the current project, can be
+//     omitted once the project is linked
+//   - `-unlink`: forget the linked file
+func sync_markdown(ctx *Command_Context) int {
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	if ctx.Has_Arg("-unlink") {
+		_, er := tx.Exec("DELETE FROM markdown_link WHERE project_id = $1", current_project_id)
+		if er != nil {
+			bone.Log_Error("During unlinking, an error occured: %s", er)
+			return common.DELETE_ERROR
+		}
+		er = tx.Commit()
+		if er != nil {
+			return common.COMMIT_ERROR
+		}
+		emit_message("Unlinked project '%s'.", current_project_name)
+		return common.OK
+	}
+
+	var path string
+	if len(ctx.Args) > 0 {
+		abs, er := filepath.Abs(ctx.Args[0])
+		if er != nil {
+			bone.Log_Error("Cannot resolve path '%s', error: %s", ctx.Args[0], er)
+			return common.INPUT_ERROR
+		}
+		path = abs
+	} else {
+		er := tx.Get(&path, "SELECT path FROM markdown_link WHERE project_id = $1", current_project_id)
+		if er != nil {
+			bone.Log_Error("Project '%s' is not linked to any file, pass the path.", current_project_name)
+			return common.INPUT_ERROR
+		}
+	}
+
+	body := ""
+	if bone.File_Exists(path) {
+		data, er := os.ReadFile(path)
+		if er != nil {
+			bone.Log_Error("Cannot read file '%s', error: %s", path, er)
+			return common.FILE_ERROR
+		}
+		body = string(data)
+	}
+	f := parse_markdown_file(body)
+
+	tasks := []*Task{}
+	er := tx.Select(&tasks, "SELECT * FROM task WHERE project_id = $1 ORDER BY created_sec ASC", current_project_id)
+	if er != nil {
+		bone.Log_Error("During task selection, an error occured: %s", er)
+		return common.SELECT_ERROR
+	}
+	project_tasks := map[int]*Task{}
+	for _, t := range tasks {
+		project_tasks[t.Id] = t
+	}
+
+	sync_items := []*Markdown_Sync_Item{}
+	er = tx.Select(
+		&sync_items,
+		"SELECT markdown_sync_item.* FROM markdown_sync_item JOIN task ON task.id = task_id WHERE project_id = $1",
+		current_project_id,
+	)
+	if er != nil {
+		bone.Log_Error("During sync state selection, an error occured: %s", er)
+		return common.SELECT_ERROR
+	}
+	synced := map[int]*Markdown_Sync_Item{}
+	for _, s := range sync_items {
+		synced[s.Task_Id] = s
+	}
+
+	conflicts := []*Markdown_Conflict{}
+	seen := map[int]bool{}
+	for i := range f.Lines {
+		item, ok := f.Items[i]
+		if !ok || item.Task_Id == 0 {
+			continue
+		}
+		t, ok := project_tasks[item.Task_Id]
+		if !ok || t.State == REJECTED || seen[t.Id] {
+			item.Removed = true
+			continue
+		}
+		seen[t.Id] = true
+
+		base, ok := synced[t.Id]
+		if !ok {
+			// Never synced, so the file is considered as the latest change.
+			base = &Markdown_Sync_Item{Title: t.Title, Checked: t.State == COMPLETED}
+		}
+		title, title_conflict := reconcile(base.Title, item.Title, t.Title)
+		checked, checked_conflict := reconcile(base.Checked, item.Checked, t.State == COMPLETED)
+		if title_conflict || checked_conflict {
+			conflicts = append(conflicts, &Markdown_Conflict{Item: item, Task: t})
+			continue
+		}
+		item.Title = title
+		item.Checked = checked
+	}
+
+	for _, t := range tasks {
+		if seen[t.Id] || t.State != ACTIVE {
+			continue
+		}
+		_, ok := synced[t.Id]
+		if ok {
+			// Was synced before, so the item is removed from the file.
+			continue
+		}
+		seen[t.Id] = true
+		f.Appended = append(f.Appended, &Markdown_Item{Prefix: "- [", Title: t.Title, Task_Id: t.Id})
+	}
+
+	tx.Rollback()
+
+	apply := func() int {
+		return apply_markdown_sync(ctx, path, f, project_tasks, synced, seen)
+	}
+	if len(conflicts) == 0 {
+		return apply()
+	}
+	return ask_markdown_conflict(conflicts, 0, apply)
+}
+
+func checkbox_str(checked bool) string {
+	if checked {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+// Prompt conflicts one by one, sync is applied after the last answer.
+func ask_markdown_conflict(conflicts []*Markdown_Conflict, i int, apply func() int) int {
+	c := conflicts[i]
+	text := fmt.Sprintf(
+		"Task changed both in tasker as '%s %s' and in Markdown as '%s %s'. Keep the tasker version?",
+		checkbox_str(c.Task.State == COMPLETED),
+		c.Task.Title,
+		checkbox_str(c.Item.Checked),
+		c.Item.Title,
+	)
+	return prompt(text, func(keep_tasker bool) int {
+		if keep_tasker {
+			c.Item.Title = c.Task.Title
+			c.Item.Checked = c.Task.State == COMPLETED
+		}
+		if i+1 < len(conflicts) {
+			return ask_markdown_conflict(conflicts, i+1, apply)
+		}
+		return apply()
+	})
+}
+
+func apply_markdown_sync(
+	ctx *Command_Context,
+	path string,
+	f *Markdown_File,
+	project_tasks map[int]*Task,
+	synced map[int]*Markdown_Sync_Item,
+	seen map[int]bool,
+) int {
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	_, er := tx.Exec(
+		"INSERT INTO markdown_link (project_id, path) VALUES ($1, $2) ON CONFLICT (project_id) DO UPDATE SET path = $2",
+		current_project_id,
+		path,
+	)
+	if er != nil {
+		bone.Log_Error("During linking, an error occured: %s", er)
+		return common.INSERT_ERROR
+	}
+
+	created := 0
+	updated := 0
+	rejected := 0
+
+	items := []*Markdown_Item{}
+	for i := range f.Lines {
+		item, ok := f.Items[i]
+		if ok && !item.Removed {
+			items = append(items, item)
+		}
+	}
+	items = append(items, f.Appended...)
+
+	for _, item := range items {
+		if item.Task_Id == 0 {
+			state := ACTIVE
+			completed_sec := 0
+			if item.Checked {
+				state = COMPLETED
+				completed_sec = int(bone.Utc())
+			}
+			result, er := tx.Exec(
+				"INSERT INTO task (title, state, created_sec, last_completed_sec, project_id) VALUES ($1, $2, $3, $4, $5)",
+				item.Title,
+				state,
+				bone.Utc(),
+				completed_sec,
+				current_project_id,
+			)
+			if er != nil {
+				bone.Log_Error("During task creation, an error occured: %s", er)
+				return common.INSERT_ERROR
+			}
+			id, _ := result.LastInsertId()
+			item.Task_Id = int(id)
+			created++
+		} else {
+			t := project_tasks[item.Task_Id]
+			changed := false
+			if t.Title != item.Title {
+				_, er := tx.Exec("UPDATE task SET title = $1 WHERE id = $2", item.Title, t.Id)
+				if er != nil {
+					bone.Log_Error("During task update, an error occured: %s", er)
+					return common.UPDATE_ERROR
+				}
+				changed = true
+			}
+			if item.Checked && t.State != COMPLETED {
+				_, er := tx.Exec("UPDATE task SET state = $1, last_completed_sec = $2 WHERE id = $3", COMPLETED, bone.Utc(), t.Id)
+				if er != nil {
+					bone.Log_Error("During task update, an error occured: %s", er)
+					return common.UPDATE_ERROR
+				}
+				changed = true
+			}
+			if !item.Checked && t.State == COMPLETED {
+				_, er := tx.Exec("UPDATE task SET state = $1 WHERE id = $2", ACTIVE, t.Id)
+				if er != nil {
+					bone.Log_Error("During task update, an error occured: %s", er)
+					return common.UPDATE_ERROR
+				}
+				changed = true
+			}
+			if changed {
+				updated++
+			}
+		}
+
+		_, er := tx.Exec(
+			"INSERT INTO markdown_sync_item (task_id, title, checked) VALUES ($1, $2, $3) ON CONFLICT (task_id) DO UPDATE SET title = $2, checked = $3",
+			item.Task_Id,
+			item.Title,
+			item.Checked,
+		)
+		if er != nil {
+			bone.Log_Error("During sync state update, an error occured: %s", er)
+			return common.UPDATE_ERROR
+		}
+	}
+
+	// Items removed from the file reject their active tasks. Removed completed
+	// ones are just forgotten.
+	for id, t := range project_tasks {
+		_, ok := synced[id]
+		if seen[id] || !ok {
+			continue
+		}
+		if t.State == ACTIVE {
+			_, er := tx.Exec("UPDATE task SET state = $1, last_rejected_sec = $2 WHERE id = $3", REJECTED, bone.Utc(), id)
+			if er != nil {
+				bone.Log_Error("During task rejection, an error occured: %s", er)
+				return common.UPDATE_ERROR
+			}
+			rejected++
+		}
+		_, er := tx.Exec("DELETE FROM markdown_sync_item WHERE task_id = $1", id)
+		if er != nil {
+			bone.Log_Error("During sync state cleanup, an error occured: %s", er)
+			return common.DELETE_ERROR
+		}
+	}
+
+	// The file is replaced only once the sync is committed, which for an atomic
+	// batch happens after its last command.
+	tmp_path, er := write_markdown_tmp(path, f.String())
+	if er != nil {
+		bone.Log_Error("Cannot write file '%s', error: %s", path, er)
+		return common.FILE_ERROR
+	}
+	tx.On_Finish(func(committed bool) {
+		if !committed {
+			os.Remove(tmp_path)
+			return
+		}
+		er := os.Rename(tmp_path, path)
+		if er != nil {
+			bone.Log_Error("Cannot write file '%s', error: %s", path, er)
+			os.Remove(tmp_path)
+		}
+	})
+
+	er = tx.Commit()
+	if er != nil {
+		return common.COMMIT_ERROR
+	}
+	emit_message("Synced with '%s': %d created, %d updated, %d rejected.", path, created, updated, rejected)
+	return common.OK
+}
+
+// Write the body next to the file, so it can be renamed over it.
+func write_markdown_tmp(path string, body string) (string, error) {
+	tmp, er := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if er != nil {
+		return "", er
+	}
+	_, er = tmp.WriteString(body)
+	if er == nil {
+		er = tmp.Chmod(0644)
+	}
+	if close_er := tmp.Close(); er == nil {
+		er = close_er
+	}
+	if er != nil {
+		os.Remove(tmp.Name())
+		return "", er
+	}
+	return tmp.Name(), nil
+}
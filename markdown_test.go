@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_markdown_parse_ok(t *testing.T) {
+	body := "# Todo\r\n\r\n- [ ] Buy milk <!-- tasker:12 -->\r\n  * [X] Call mom\r\n- [ ] \r\nText\r\n"
+	f := parse_markdown_file(body)
+	assert.Equal(t, "\r\n", f.Line_Sep)
+	assert.True(t, f.Final_Sep)
+	assert.Len(t, f.Lines, 6)
+	assert.Len(t, f.Items, 2)
+	assert.Equal(t, &Markdown_Item{Prefix: "- [", Title: "Buy milk", Task_Id: 12}, f.Items[2])
+	assert.Equal(t, &Markdown_Item{Prefix: "  * [", Checked: true, Title: "Call mom"}, f.Items[3])
+
+	f.Items[3].Task_Id = 13
+	f.Items[2].Removed = true
+	f.Appended = append(f.Appended, &Markdown_Item{Prefix: "- [", Title: "Water plants", Task_Id: 14})
+	assert.Equal(
+		t,
+		"# Todo\r\n\r\n  * [x] Call mom <!-- tasker:13 -->\r\n- [ ] \r\nText\r\n- [ ] Water plants <!-- tasker:14 -->\r\n",
+		f.String(),
+	)
+
+	f = parse_markdown_file("")
+	assert.Empty(t, f.Lines)
+	f.Appended = append(f.Appended, &Markdown_Item{Prefix: "- [", Title: "First", Task_Id: 1})
+	assert.Equal(t, "- [ ] First <!-- tasker:1 -->\n", f.String())
+}
+
+func Test_reconcile_ok(t *testing.T) {
+	cases := []struct {
+		name     string
+		base     string
+		markdown string
+		tasker   string
+		value    string
+		conflict bool
+	}{
+		{"unchanged", "a", "a", "a", "a", false},
+		{"changed in markdown", "a", "b", "a", "b", false},
+		{"changed in tasker", "a", "a", "b", "b", false},
+		{"changed the same way", "a", "b", "b", "b", false},
+		{"changed differently", "a", "b", "c", "a", true},
+	}
+	for _, c := range cases {
+		value, conflict := reconcile(c.base, c.markdown, c.tasker)
+		assert.Equal(t, c.value, value, c.name)
+		assert.Equal(t, c.conflict, conflict, c.name)
+	}
+
+	checked, conflict := reconcile(false, true, false)
+	assert.True(t, checked)
+	assert.False(t, conflict)
+}
+
+func Test_sync_markdown_ok(t *testing.T) {
+	project_id, _ := with_project_output(t, "markdown", "plain")
+	tx := db.Begin()
+	kept_id, er := insert_task(tx, "Kept", project_id)
+	assert.Nil(t, er)
+	assert.Nil(t, tx.Commit())
+
+	path := filepath.Join(t.TempDir(), "todo.md")
+	assert.Nil(t, os.WriteFile(path, []byte("# Todo\n- [ ] One\n- [x] Two\n"), 0644))
+	read := func() *Markdown_File {
+		data, er := os.ReadFile(path)
+		assert.Nil(t, er)
+		return parse_markdown_file(string(data))
+	}
+	get := func(task_id int) *Task {
+		tx := db.Begin()
+		defer tx.Rollback()
+		task, er := get_task(tx, task_id)
+		assert.Nil(t, er)
+		return task
+	}
+	write := func(items ...*Markdown_Item) {
+		f := &Markdown_File{Lines: []string{"# Todo"}, Items: map[int]*Markdown_Item{}, Line_Sep: "\n", Final_Sep: true}
+		f.Appended = items
+		assert.Nil(t, os.WriteFile(path, []byte(f.String()), 0644))
+	}
+
+	// New items create tasks, the new task is appended.
+	assert.Equal(t, common.OK, process_input("sync "+path))
+	f := read()
+	assert.Equal(t, "# Todo", f.Lines[0])
+	one, two, kept := f.Items[1], f.Items[2], f.Items[3]
+	assert.Equal(t, "One", one.Title)
+	assert.Equal(t, "Two", two.Title)
+	assert.Equal(t, kept_id, kept.Task_Id)
+	assert.Equal(t, ACTIVE, get(one.Task_Id).State)
+	assert.Equal(t, COMPLETED, get(two.Task_Id).State)
+
+	// The removed item rejects the task, the title changed in tasker is written
+	// to the file.
+	tx = db.Begin()
+	_, er = tx.Exec("UPDATE task SET title = 'Kept renamed' WHERE id = $1", kept_id)
+	assert.Nil(t, er)
+	assert.Nil(t, tx.Commit())
+	write(&Markdown_Item{Prefix: "- [", Checked: true, Title: "Two", Task_Id: two.Task_Id}, kept)
+	assert.Equal(t, common.OK, process_input("sync"))
+	assert.Equal(t, REJECTED, get(one.Task_Id).State)
+	f = read()
+	assert.Len(t, f.Items, 2)
+	assert.Equal(t, "Kept renamed", f.Items[2].Title)
+
+	// Both sides changed: the first conflict keeps the Markdown version, the
+	// second one keeps the tasker version.
+	tx = db.Begin()
+	_, er = tx.Exec("UPDATE task SET title = 'Two in tasker', state = $1 WHERE id = $2", ACTIVE, two.Task_Id)
+	assert.Nil(t, er)
+	_, er = tx.Exec("UPDATE task SET title = 'Kept in tasker' WHERE id = $1", kept_id)
+	assert.Nil(t, er)
+	assert.Nil(t, tx.Commit())
+	write(
+		&Markdown_Item{Prefix: "- [", Title: "Two in markdown", Task_Id: two.Task_Id},
+		&Markdown_Item{Prefix: "- [", Title: "Kept in markdown", Task_Id: kept_id},
+	)
+	assert.Equal(t, common.OK, process_input("sync"))
+	assert.True(t, prompted)
+	assert.Equal(t, common.OK, process_input("n"))
+	assert.True(t, prompted)
+	assert.Equal(t, common.OK, process_input("y"))
+	assert.False(t, prompted)
+	assert.Equal(t, "Two in markdown", get(two.Task_Id).Title)
+	assert.Equal(t, "Kept in tasker", get(kept_id).Title)
+	f = read()
+	assert.Equal(t, "Two in markdown", f.Items[1].Title)
+	assert.False(t, f.Items[1].Checked)
+	assert.Equal(t, "Kept in tasker", f.Items[2].Title)
+
+	assert.Equal(t, common.OK, process_input("sync -unlink"))
+	assert.Equal(t, common.INPUT_ERROR, process_input("sync"))
+}
@@ -0,0 +1,6 @@
+-- Free-form labels of the task, e.g. todo.txt contexts.
+CREATE TABLE task_tag(
+	task_id INTEGER NOT NULL REFERENCES task(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	PRIMARY KEY (task_id, name)
+);
@@ -0,0 +1,3 @@
+-- JSON object of the fields which imported tasks had, but we have no columns
+-- for, e.g. Taskwarrior uuid or annotations. Kept to export them back.
+ALTER TABLE task ADD COLUMN extra TEXT DEFAULT NULL;
@@ -0,0 +1,12 @@
+-- Markdown checklist file linked to the project by the `sync` command.
+CREATE TABLE markdown_link(
+	project_id INTEGER PRIMARY KEY REFERENCES project(id) ON DELETE CASCADE,
+	path TEXT NOT NULL
+);
+
+-- Task as of the last sync, to tell which side has changed since.
+CREATE TABLE markdown_sync_item(
+	task_id INTEGER PRIMARY KEY REFERENCES task(id) ON DELETE CASCADE,
+	title TEXT NOT NULL,
+	checked INTEGER NOT NULL
+);
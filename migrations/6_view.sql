@@ -0,0 +1,16 @@
+-- Named argument set of the `show` command, run by the `v` command. Global
+-- views have no project, project views are visible only from their project.
+CREATE TABLE view(
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	args TEXT NOT NULL,
+	project_id INTEGER DEFAULT NULL REFERENCES project(id) ON DELETE CASCADE
+);
+
+-- Projects the view shows tasks of. A view without them shows the current
+-- project.
+CREATE TABLE view_project(
+	view_id INTEGER NOT NULL REFERENCES view(id) ON DELETE CASCADE,
+	project_id INTEGER NOT NULL REFERENCES project(id) ON DELETE CASCADE,
+	PRIMARY KEY (view_id, project_id)
+);
@@ -0,0 +1,3 @@
+-- Estimated effort of the task, used by `next` to favor the quick ones. Null
+-- when not estimated.
+ALTER TABLE task ADD COLUMN effort_sec INTEGER DEFAULT NULL;
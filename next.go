@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/term"
+	"time"
+)
+
+// Recommendation of the active tasks to work on. Each task is scored by the
+// weighted sum of the factors from 0 to 1:
+//   - `priority`: 0 for sometime later, 0.5 for this week, 1 for today
+//   - `schedule`: 1 when due or overdue, halving in a day before, 0 when not
+//     scheduled
+//   - `age`: days since the creation, 0.5 at 30 days, approaching 1
+//   - `effort`: 1 for the instant tasks, 0.5 at an hour or when not estimated
+//
+// Weights are set in the `next` section of user.cfg:
+//
+//	[next]
+//	priority = 3
+//	schedule = 2
+//	age = 1
+//	effort = 1
+
+const NEXT_AGE_HALF_DAYS = 30
+const NEXT_UNESTIMATED_EFFORT = 0.5
+
+var NEXT_FACTORS = []string{"priority", "schedule", "age", "effort"}
+
+var NEXT_DEFAULT_WEIGHTS = map[string]float64{
+	"priority": 3,
+	"schedule": 2,
+	"age":      1,
+	"effort":   1,
+}
+
+type Score_Part struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+	Value  float64 `json:"value"`
+}
+
+type Task_Score struct {
+	Task  *Task
+	Score float64
+	Parts []*Score_Part
+}
+
+type Next_Result struct {
+	Scores []*Task_Score
+	// Show the score breakdown.
+	Why bool
+}
+
+type Json_Scored_Task struct {
+	*Json_Task
+	Score float64       `json:"score"`
+	Parts []*Score_Part `json:"parts"`
+}
+
+// Value of the schedule factor: days until the end of the all day schedules,
+// or until the exact time.
+func schedule_factor(t *Task, now time.Time) float64 {
+	if t.Schedule == nil {
+		return 0
+	}
+	schedule, ok := parse_schedule(*t.Schedule)
+	if !ok {
+		return 0
+	}
+	due := schedule.Start.In(now.Location())
+	if schedule.Is_All_Day() {
+		start := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, now.Location())
+		due = (&Schedule{Start: start, Precision: schedule.Precision}).End()
+	}
+	days := due.Sub(now).Hours() / 24
+	if schedule.Is_All_Day() {
+		// Tasks are due within their last day.
+		days -= 1
+	}
+	return math.Pow(0.5, max(days, 0))
+}
+
+// Score the task by the weights of the factors.
+func score_task(t *Task, weights map[string]float64, now time.Time) *Task_Score {
+	values := map[string]float64{
+		"priority": float64(min(max(t.Priority, 0), TODAY_PRIORITY)) / TODAY_PRIORITY,
+		"schedule": schedule_factor(t, now),
+	}
+	age_days := max(now.Sub(time.Unix(int64(t.Created_Sec), 0)).Hours()/24, 0)
+	values["age"] = age_days / (age_days + NEXT_AGE_HALF_DAYS)
+	values["effort"] = NEXT_UNESTIMATED_EFFORT
+	if t.Effort_Sec != nil {
+		values["effort"] = 1 / (1 + float64(*t.Effort_Sec)/3600)
+	}
+
+	score := &Task_Score{Task: t}
+	for _, name := range NEXT_FACTORS {
+		part := &Score_Part{Name: name, Weight: weights[name], Value: values[name]}
+		score.Parts = append(score.Parts, part)
+		score.Score += part.Weight * part.Value
+	}
+	return score
+}
+
+func next_weights() map[string]float64 {
+	weights := map[string]float64{}
+	for _, name := range NEXT_FACTORS {
+		weights[name] = bone.Config.Get_Float("next", name, NEXT_DEFAULT_WEIGHTS[name])
+	}
+	return weights
+}
+
+// Recommend the tasks of the current project to work on.
+//
+// Args:
+//   - 1 (default=1): number of the tasks to recommend
+//   - `-why`: explain the scores
+//   - `-everything`: recommend tasks of every project
+func next(ctx *Command_Context) int {
+	count := 1
+	if len(ctx.Args) > 0 && !strings.HasPrefix(ctx.Args[0], "-") {
+		n, er := strconv.Atoi(ctx.Args[0])
+		if er != nil || n <= 0 {
+			bone.Log_Error("Expected positive number of tasks, got '%s'.", ctx.Args[0])
+			return common.INPUT_ERROR
+		}
+		count = n
+	}
+
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	query := "SELECT * FROM task WHERE state = $1"
+	args := []any{ACTIVE}
+	if !ctx.Has_Arg("-everything") {
+		query += " AND project_id = $2"
+		args = append(args, current_project_id)
+	}
+	tasks := []*Task{}
+	er := tx.Select(&tasks, query+" ORDER BY created_sec ASC, id ASC", args...)
+	if er != nil {
+		bone.Log_Error("During task selection, an error occured: %s", er)
+		return common.SELECT_ERROR
+	}
+
+	weights := next_weights()
+	now := time.Now()
+	scores := []*Task_Score{}
+	for _, t := range tasks {
+		scores = append(scores, score_task(t, weights, now))
+	}
+	// Older tasks go first among the equal scores.
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Score > scores[j].Score })
+	scores = scores[:min(count, len(scores))]
+
+	targets := []*Task{}
+	for _, s := range scores {
+		targets = append(targets, s.Task)
+	}
+	set_hooks(targets)
+	emit(&Next_Result{Scores: scores, Why: ctx.Has_Arg("-why")})
+	return common.OK
+}
+
+func draw_next(w io.Writer, r *Next_Result, columns int, theme *Theme, completion_mark func(t *Task) string) {
+	if len(r.Scores) == 0 {
+		fmt.Fprint(w, "No tasks\n")
+		return
+	}
+	rows := [][]string{}
+	for i, s := range r.Scores {
+		rows = append(rows, []string{
+			fmt.Sprintf("|%d|", i+1),
+			completion_mark(s.Task),
+			theme.Get_Priority_Mark(s.Task),
+			fmt.Sprintf("%.2f", s.Score),
+			s.Task.Title,
+		})
+	}
+	var b bytes.Buffer
+	write_table(&b, rows, columns)
+	lines := strings.SplitAfter(b.String(), "\n")
+
+	for i, s := range r.Scores {
+		fmt.Fprint(w, lines[i])
+		if !r.Why {
+			continue
+		}
+		indent := strings.Repeat(" ", term.Width(rows[i][0])+1)
+		for _, part := range s.Parts {
+			fmt.Fprintf(
+				w,
+				"%s%-8s %s * %.2f = %.2f\n",
+				indent,
+				part.Name,
+				strconv.FormatFloat(part.Weight, 'g', -1, 64),
+				part.Value,
+				part.Weight*part.Value,
+			)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_schedule_factor_ok(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	factor := func(schedule string) float64 {
+		return schedule_factor(&Task{Schedule: bone.Atop(schedule)}, now)
+	}
+	assert.Equal(t, 0.0, schedule_factor(&Task{}, now))
+	assert.Equal(t, 1.0, factor("2026-10-01"))
+	assert.Equal(t, 1.0, factor("2026-10-19"))
+	assert.InDelta(t, 0.5, factor("2026-10-20 12:00:00"), 0.001)
+	assert.InDelta(t, 0.25, factor("2026-10-21 12:00:00"), 0.001)
+	assert.Less(t, factor("2026-11"), factor("2026-10-25"))
+}
+
+func Test_score_task_ok(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	weights := map[string]float64{"priority": 3, "schedule": 2, "age": 1, "effort": 2}
+	effort := 3600
+	task := &Task{
+		Priority:    THIS_WEEK_PRIORITY,
+		Created_Sec: int(now.AddDate(0, 0, -30).Unix()),
+		Effort_Sec:  &effort,
+	}
+	score := score_task(task, weights, now)
+	assert.InDelta(t, 3*0.5+0.5+2*0.5, score.Score, 0.001)
+	assert.Equal(t, []string{"priority", "schedule", "age", "effort"}, []string{
+		score.Parts[0].Name, score.Parts[1].Name, score.Parts[2].Name, score.Parts[3].Name,
+	})
+	assert.Equal(t, 0.0, score.Parts[1].Value)
+
+	// Unestimated task scores neither better nor worse than the estimated ones.
+	task.Effort_Sec = nil
+	score = score_task(task, weights, now)
+	assert.Equal(t, NEXT_UNESTIMATED_EFFORT, score.Parts[3].Value)
+	short, long := 60, 8*3600
+	task.Effort_Sec = &short
+	assert.Less(t, NEXT_UNESTIMATED_EFFORT, score_task(task, weights, now).Parts[3].Value)
+	task.Effort_Sec = &long
+	assert.Greater(t, NEXT_UNESTIMATED_EFFORT, score_task(task, weights, now).Parts[3].Value)
+}
+
+func Test_draw_next_ok(t *testing.T) {
+	var output bytes.Buffer
+	draw_next(&output, &Next_Result{
+		Scores: []*Task_Score{{
+			Task:  &Task{Title: "Write", Priority: TODAY_PRIORITY},
+			Score: 3.5,
+			Parts: []*Score_Part{{Name: "priority", Weight: 3, Value: 1}, {Name: "age", Weight: 1.5, Value: 0.3333}},
+		}},
+		Why: true,
+	}, 0, plain_theme, plain_completion_mark)
+	assert.Equal(
+		t,
+		"|1| . !! 3.50 Write\n"+
+			"    priority 3 * 1.00 = 3.00\n"+
+			"    age      1.5 * 0.33 = 0.50\n",
+		output.String(),
+	)
+}
+
+func Test_next_ok(t *testing.T) {
+	project_id, output := with_project_output(t, "next", "json")
+	tx := db.Begin()
+	_, er := insert_task(tx, "Plain", project_id)
+	assert.Nil(t, er)
+	quick_id, er := insert_task(tx, "Quick", project_id)
+	assert.Nil(t, er)
+	urgent_id, er := insert_task(tx, "Urgent", project_id)
+	assert.Nil(t, er)
+	assert.Nil(t, set_task_priority(tx, urgent_id, TODAY_PRIORITY))
+	assert.Nil(t, tx.Commit())
+
+	assert.Equal(t, common.OK, process_input("s"))
+	assert.Equal(t, quick_id, hooks[1].(*Task).Id)
+	assert.Equal(t, common.OK, process_input("u 2 -e 30m"))
+	assert.Equal(t, common.INPUT_ERROR, process_input("u 2 -e soon"))
+
+	output.Reset()
+	assert.Equal(t, common.OK, process_input("next 5 -why"))
+	titles := []string{}
+	for _, hook := range hooks {
+		titles = append(titles, hook.(*Task).Title)
+	}
+	assert.Equal(t, []string{"Urgent", "Quick", "Plain"}, titles)
+	assert.Contains(t, output.String(), `"effort_sec":1800`)
+	assert.Contains(t, output.String(), `"parts":[{"name":"priority","weight":3,"value":1}`)
+
+	assert.Equal(t, common.OK, process_input("next"))
+	assert.Len(t, hooks, 1)
+	assert.Equal(t, common.INPUT_ERROR, process_input("next 0"))
+}
@@ -0,0 +1,749 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/term"
+	"time"
+	"unicode/utf8"
+)
+
+// Query language of the `show` filter, e.g.
+// `state:active prio>=1 tag:x created>2026-01-01 sched<+7d project:work sort:-prio,created`.
+//
+// Terms are joined by `and`, which may be omitted, and by `or`; `not` and
+// parentheses group them. A term is either `FIELD OP VALUE`, a bare word
+// searched in the title, or one of the former `show` flags:
+//   - `-a`: `state:all`, sorted by state
+//   - `-c`: `state:completed`
+//   - `-r`: `state:rejected`
+//   - `-ocompleted`: `sort:completed`
+//   - `-orejected`: `sort:rejected`
+//   - `-reverse`: reverse the sort
+//   - `-screated`, `-scompleted`, `-srejected`: only change the output
+//   - `-limit N`, `-offset N`: select the page of the matching tasks, hooks
+//     are still numbered over all of them; only at the top level
+//
+// Fields take the operators `:` (same as `=`), `!=`, `<`, `<=`, `>`, `>=`:
+//   - `state`: `active`, `completed`, `rejected` or `all`, only `:` and `!=`
+//   - `prio`: number, or `later`, `week`, `today`
+//   - `tag`, `project`, `title`: only `:` and `!=`, project `*` stands for
+//     every project, title matches the substring
+//   - `created`, `completed`, `rejected`, `sched`: date `YYYY[-MM[-DD]]`,
+//     `today`, `tomorrow`, `yesterday`, or relative `+7d`, `-2w`, `+1m`,
+//     `-1y`. The date stands for the whole period, so `created:2026-01`
+//     matches the month. `sched:none` matches unscheduled tasks.
+//   - `sort`: keys `prio`, `created`, `completed`, `rejected`, `sched`,
+//     `title`, `state`, `id` separated by commas, `-` prefix sorts the key
+//     descending; only at the top level
+//
+// Queries are compiled to SQL with parameters. Without the `state` term only
+// active tasks are selected, without the `project` term only the tasks of the
+// passed projects.
+
+type Query_Error struct {
+	// Starting from 1, in runes.
+	Column  int
+	Message string
+}
+
+func (er *Query_Error) Error() string {
+	return fmt.Sprintf("column %d: %s", er.Column, er.Message)
+}
+
+func query_error(column int, message string, args ...any) *Query_Error {
+	return &Query_Error{Column: column, Message: fmt.Sprintf(message, args...)}
+}
+
+type Query_Node interface {
+	compile(c *query_compiler) (string, error)
+}
+
+type Query_And struct {
+	Nodes []Query_Node
+}
+
+type Query_Or struct {
+	Nodes []Query_Node
+}
+
+type Query_Not struct {
+	Node Query_Node
+}
+
+type Query_Term struct {
+	Column int
+	Field  string
+	Op     string
+	Value  string
+}
+
+type Query_Sort_Key struct {
+	Key        string
+	Descending bool
+}
+
+type Task_Query struct {
+	// Nil if there are no terms.
+	Where       Query_Node
+	Sort        []Query_Sort_Key
+	Reverse     bool
+	State_Sort  bool
+	Has_State   bool
+	Has_Project bool
+	// Zero limit doesn't limit.
+	Limit  int
+	Offset int
+}
+
+// Terms the former `show` flags stand for. Empty ones don't filter.
+var QUERY_SHORTHANDS = map[string]string{
+	"-a":          "state:all",
+	"-c":          "state:completed",
+	"-r":          "state:rejected",
+	"-ocompleted": "sort:completed",
+	"-orejected":  "sort:rejected",
+	"-reverse":    "",
+	"-screated":   "",
+	"-scompleted": "",
+	"-srejected":  "",
+}
+
+var QUERY_FIELDS = map[string]string{
+	"state":     "state",
+	"prio":      "prio",
+	"priority":  "prio",
+	"tag":       "tag",
+	"project":   "project",
+	"title":     "title",
+	"created":   "created",
+	"completed": "completed",
+	"rejected":  "rejected",
+	"sched":     "sched",
+	"schedule":  "sched",
+	"sort":      "sort",
+}
+
+// Fields, which are compared only for equality.
+var QUERY_EQUALITY_FIELDS = map[string]bool{
+	"state":   true,
+	"tag":     true,
+	"project": true,
+	"title":   true,
+	"sort":    true,
+}
+
+var QUERY_SORT_COLUMNS = map[string]string{
+	"prio":      "completion_priority",
+	"priority":  "completion_priority",
+	"created":   "created_sec",
+	"completed": "last_completed_sec",
+	"rejected":  "last_rejected_sec",
+	"sched":     "schedule",
+	"schedule":  "schedule",
+	"title":     "title",
+	"state":     "state",
+	"id":        "id",
+}
+
+var QUERY_TIME_COLUMNS = map[string]string{
+	"created":   "created_sec",
+	"completed": "last_completed_sec",
+	"rejected":  "last_rejected_sec",
+}
+
+var QUERY_PRIORITIES = map[string]int{
+	"later": SOMETIME_LATER_PRIORITY,
+	"week":  THIS_WEEK_PRIORITY,
+	"today": TODAY_PRIORITY,
+}
+
+var query_term_regex = regexp.MustCompile(`^([a-z_]+)(:|!=|>=|<=|=|<|>)(.*)$`)
+var query_relative_date_regex = regexp.MustCompile(`^([+-]\d+)([dwmy])$`)
+
+type query_token struct {
+	Column int
+	Text   string
+}
+
+// Split the input by spaces, parentheses are tokens on their own.
+func lex_query(input string) []query_token {
+	tokens := []query_token{}
+	word := []rune{}
+	column := 0
+	flush := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, query_token{Column: column - len(word), Text: string(word)})
+			word = word[:0]
+		}
+	}
+	for _, r := range input {
+		column++
+		switch r {
+		case ' ', '\t':
+			flush()
+		case '(', ')':
+			flush()
+			tokens = append(tokens, query_token{Column: column, Text: string(r)})
+		default:
+			word = append(word, r)
+		}
+	}
+	column++
+	flush()
+	return tokens
+}
+
+type query_parser struct {
+	tokens []query_token
+	pos    int
+	depth  int
+	// Column past the input, to point at the missing tokens.
+	end   int
+	query *Task_Query
+}
+
+func parse_task_query(input string) (*Task_Query, error) {
+	p := &query_parser{
+		tokens: lex_query(input),
+		end:    utf8.RuneCountInString(input) + 1,
+		query:  &Task_Query{},
+	}
+	node, er := p.parse_or()
+	if er != nil {
+		return nil, er
+	}
+	if p.pos < len(p.tokens) {
+		return nil, query_error(p.tokens[p.pos].Column, "unexpected ')'")
+	}
+	p.query.Where = node
+	p.query.Has_State = query_conjunction_has(node, "state")
+	p.query.Has_Project = query_conjunction_has(node, "project")
+	return p.query, nil
+}
+
+// Whether every selected task has to match a term on the field, i.e. the term
+// is joined to the rest of the query only by 'and'.
+func query_conjunction_has(node Query_Node, field string) bool {
+	switch n := node.(type) {
+	case *Query_Term:
+		return n.Field == field
+	case *Query_And:
+		return slices.ContainsFunc(n.Nodes, func(node Query_Node) bool {
+			return query_conjunction_has(node, field)
+		})
+	}
+	return false
+}
+
+func (p *query_parser) peek() (query_token, bool) {
+	if p.pos >= len(p.tokens) {
+		return query_token{Column: p.end}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *query_parser) accept(text string) bool {
+	token, ok := p.peek()
+	if ok && token.Text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *query_parser) parse_or() (Query_Node, error) {
+	node, er := p.parse_and()
+	if er != nil {
+		return nil, er
+	}
+	nodes := []Query_Node{}
+	for {
+		token, _ := p.peek()
+		if !p.accept("or") {
+			break
+		}
+		if node == nil {
+			return nil, query_error(token.Column, "expected a term before 'or'")
+		}
+		nodes = append(nodes, node)
+		next, _ := p.peek()
+		node, er = p.parse_and()
+		if er != nil {
+			return nil, er
+		}
+		if node == nil {
+			return nil, query_error(next.Column, "expected a term after 'or'")
+		}
+	}
+	if len(nodes) == 0 {
+		return node, nil
+	}
+	return &Query_Or{Nodes: append(nodes, node)}, nil
+}
+
+func (p *query_parser) parse_and() (Query_Node, error) {
+	nodes := []Query_Node{}
+	for {
+		token, ok := p.peek()
+		if !ok || token.Text == ")" || token.Text == "or" {
+			break
+		}
+		if p.accept("and") {
+			continue
+		}
+		node, er := p.parse_not()
+		if er != nil {
+			return nil, er
+		}
+		if node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	switch len(nodes) {
+	case 0:
+		return nil, nil
+	case 1:
+		return nodes[0], nil
+	default:
+		return &Query_And{Nodes: nodes}, nil
+	}
+}
+
+func (p *query_parser) parse_not() (Query_Node, error) {
+	token, ok := p.peek()
+	if !ok || token.Text == ")" || token.Text == "or" {
+		return nil, nil
+	}
+	if p.accept("not") {
+		next, _ := p.peek()
+		node, er := p.parse_not()
+		if er != nil {
+			return nil, er
+		}
+		if node == nil {
+			return nil, query_error(next.Column, "expected a term after 'not'")
+		}
+		return &Query_Not{Node: node}, nil
+	}
+	if p.accept("(") {
+		p.depth++
+		node, er := p.parse_or()
+		if er != nil {
+			return nil, er
+		}
+		if !p.accept(")") {
+			next, _ := p.peek()
+			return nil, query_error(next.Column, "expected ')'")
+		}
+		p.depth--
+		if node == nil {
+			return nil, query_error(token.Column, "empty group")
+		}
+		return node, nil
+	}
+	p.pos++
+	if strings.HasPrefix(token.Text, "-") {
+		return p.parse_shorthand(token)
+	}
+	return p.parse_term(token)
+}
+
+func (p *query_parser) parse_shorthand(token query_token) (Query_Node, error) {
+	if token.Text == "-limit" || token.Text == "-offset" {
+		return nil, p.parse_page_flag(token)
+	}
+	expansion, ok := QUERY_SHORTHANDS[token.Text]
+	if !ok {
+		return nil, query_error(token.Column, "unknown flag '%s'", token.Text)
+	}
+	switch token.Text {
+	case "-reverse":
+		p.query.Reverse = true
+	case "-a":
+		p.query.State_Sort = true
+	}
+	if expansion == "" {
+		return nil, nil
+	}
+	return p.parse_term(query_token{Column: token.Column, Text: expansion})
+}
+
+func (p *query_parser) parse_page_flag(token query_token) error {
+	if p.depth > 0 {
+		return query_error(token.Column, "'%s' should be at the top level", token.Text)
+	}
+	value, ok := p.peek()
+	if !ok {
+		return query_error(value.Column, "expected number after '%s'", token.Text)
+	}
+	p.pos++
+	n, er := strconv.Atoi(value.Text)
+	if token.Text == "-limit" {
+		if er != nil || n <= 0 {
+			return query_error(value.Column, "expected positive number, got '%s'", value.Text)
+		}
+		p.query.Limit = n
+		return nil
+	}
+	if er != nil || n < 0 {
+		return query_error(value.Column, "expected non-negative number, got '%s'", value.Text)
+	}
+	p.query.Offset = n
+	return nil
+}
+
+func (p *query_parser) parse_term(token query_token) (Query_Node, error) {
+	match := query_term_regex.FindStringSubmatch(token.Text)
+	if match == nil {
+		return &Query_Term{Column: token.Column, Field: "title", Op: ":", Value: token.Text}, nil
+	}
+	field, ok := QUERY_FIELDS[match[1]]
+	if !ok {
+		return nil, query_error(token.Column, "unknown field '%s'", match[1])
+	}
+	op := match[2]
+	op_column := token.Column + utf8.RuneCountInString(match[1])
+	value_column := op_column + len(op)
+	if op == "=" {
+		op = ":"
+	}
+	if QUERY_EQUALITY_FIELDS[field] && op != ":" && op != "!=" {
+		return nil, query_error(op_column, "field '%s' takes only ':' and '!='", match[1])
+	}
+	if match[3] == "" {
+		return nil, query_error(value_column, "expected value after '%s%s'", match[1], match[2])
+	}
+
+	switch field {
+	case "sort":
+		if op != ":" {
+			return nil, query_error(op_column, "field 'sort' takes only ':'")
+		}
+		if p.depth > 0 {
+			return nil, query_error(token.Column, "sort should be at the top level")
+		}
+		keys := []Query_Sort_Key{}
+		column := value_column
+		for _, key := range strings.Split(match[3], ",") {
+			descending := strings.HasPrefix(key, "-")
+			name := strings.TrimPrefix(key, "-")
+			if _, ok := QUERY_SORT_COLUMNS[name]; !ok {
+				return nil, query_error(column, "unknown sort key '%s'", name)
+			}
+			keys = append(keys, Query_Sort_Key{Key: name, Descending: descending})
+			column += utf8.RuneCountInString(key) + 1
+		}
+		p.query.Sort = keys
+		return nil, nil
+	}
+	return &Query_Term{Column: value_column, Field: field, Op: op, Value: match[3]}, nil
+}
+
+// Shift the date by the number of days, weeks, months or years.
+func add_date_units(date time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "w":
+		return date.AddDate(0, 0, 7*n)
+	case "m":
+		return date.AddDate(0, n, 0)
+	case "y":
+		return date.AddDate(n, 0, 0)
+	default:
+		return date.AddDate(0, 0, n)
+	}
+}
+
+// Period the date stands for: the day for the relative dates, the year, month
+// or day for the absolute ones. Days start in the location of the current
+// time.
+func parse_query_date(value string, now time.Time) (*Schedule, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch value {
+	case "today":
+		return &Schedule{Start: today, Precision: SCHEDULE_DAY}, true
+	case "tomorrow":
+		return &Schedule{Start: today.AddDate(0, 0, 1), Precision: SCHEDULE_DAY}, true
+	case "yesterday":
+		return &Schedule{Start: today.AddDate(0, 0, -1), Precision: SCHEDULE_DAY}, true
+	}
+	match := query_relative_date_regex.FindStringSubmatch(value)
+	if match != nil {
+		n, er := strconv.Atoi(match[1])
+		if er != nil {
+			return nil, false
+		}
+		return &Schedule{Start: add_date_units(today, n, match[2]), Precision: SCHEDULE_DAY}, true
+	}
+	schedule, ok := parse_schedule(value)
+	if !ok || schedule.Precision == SCHEDULE_TIME {
+		return nil, false
+	}
+	start := schedule.Start
+	schedule.Start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, now.Location())
+	return schedule, true
+}
+
+type query_compiler struct {
+	now  time.Time
+	args []any
+}
+
+// Add the parameter, returning its placeholder.
+func (c *query_compiler) arg(value any) string {
+	c.args = append(c.args, value)
+	return fmt.Sprintf("$%d", len(c.args))
+}
+
+func compile_query_nodes(c *query_compiler, nodes []Query_Node, separator string) (string, error) {
+	parts := []string{}
+	for _, node := range nodes {
+		part, er := node.compile(c)
+		if er != nil {
+			return "", er
+		}
+		parts = append(parts, part)
+	}
+	return "(" + strings.Join(parts, separator) + ")", nil
+}
+
+func (n *Query_And) compile(c *query_compiler) (string, error) {
+	return compile_query_nodes(c, n.Nodes, " AND ")
+}
+
+func (n *Query_Or) compile(c *query_compiler) (string, error) {
+	return compile_query_nodes(c, n.Nodes, " OR ")
+}
+
+func (n *Query_Not) compile(c *query_compiler) (string, error) {
+	part, er := n.Node.compile(c)
+	if er != nil {
+		return "", er
+	}
+	return "NOT " + part, nil
+}
+
+// Comparison of the column with the period `[start, end)`.
+func compare_period(column string, op string, start string, end string) string {
+	switch op {
+	case "!=":
+		return fmt.Sprintf("(%s < %s OR %s >= %s)", column, start, column, end)
+	case "<":
+		return fmt.Sprintf("%s < %s", column, start)
+	case "<=":
+		return fmt.Sprintf("%s < %s", column, end)
+	case ">":
+		return fmt.Sprintf("%s >= %s", column, end)
+	case ">=":
+		return fmt.Sprintf("%s >= %s", column, start)
+	default:
+		return fmt.Sprintf("(%s >= %s AND %s < %s)", column, start, column, end)
+	}
+}
+
+func (n *Query_Term) compile(c *query_compiler) (string, error) {
+	negation := ""
+	always := "1 = 1"
+	if n.Op == "!=" {
+		negation = "NOT "
+		always = "1 = 0"
+	}
+
+	switch n.Field {
+	case "state":
+		if n.Value == "all" {
+			return always, nil
+		}
+		state, ok := parse_state_name(n.Value)
+		if !ok {
+			return "", query_error(n.Column, "unknown state '%s'", n.Value)
+		}
+		if n.Op == "!=" {
+			return fmt.Sprintf("state <> %s", c.arg(state)), nil
+		}
+		return fmt.Sprintf("state = %s", c.arg(state)), nil
+
+	case "prio":
+		priority, ok := QUERY_PRIORITIES[n.Value]
+		if !ok {
+			var er error
+			priority, er = strconv.Atoi(n.Value)
+			if er != nil {
+				return "", query_error(n.Column, "unknown priority '%s'", n.Value)
+			}
+		}
+		op := n.Op
+		switch op {
+		case ":":
+			op = "="
+		case "!=":
+			op = "<>"
+		}
+		return fmt.Sprintf("completion_priority %s %s", op, c.arg(priority)), nil
+
+	case "tag":
+		return fmt.Sprintf("id %sIN (SELECT task_id FROM task_tag WHERE name = %s)", negation, c.arg(n.Value)), nil
+
+	case "project":
+		if n.Value == "*" {
+			return always, nil
+		}
+		return fmt.Sprintf("project_id %sIN (SELECT id FROM project WHERE title = %s)", negation, c.arg(n.Value)), nil
+
+	case "title":
+		replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+		pattern := "%" + replacer.Replace(strings.ToLower(n.Value)) + "%"
+		return fmt.Sprintf(`LOWER(title) %sLIKE %s ESCAPE '\'`, negation, c.arg(pattern)), nil
+
+	case "sched":
+		if n.Value == "none" {
+			switch n.Op {
+			case ":":
+				return "schedule IS NULL", nil
+			case "!=":
+				return "schedule IS NOT NULL", nil
+			default:
+				return "", query_error(n.Column, "'none' takes only ':' and '!='")
+			}
+		}
+		period, ok := parse_query_date(n.Value, c.now)
+		if !ok {
+			return "", query_error(n.Column, "invalid date '%s'", n.Value)
+		}
+		// All day schedules are the dates of the local calendar, exact time ones
+		// are in UTC.
+		layout := SCHEDULE_LAYOUTS[period.Precision]
+		start := c.arg(period.Start.Format(layout))
+		end := c.arg(period.End().Format(layout))
+		time_layout := SCHEDULE_LAYOUTS[SCHEDULE_TIME]
+		time_start := c.arg(period.Start.UTC().Format(time_layout))
+		time_end := c.arg(period.End().UTC().Format(time_layout))
+		return fmt.Sprintf(
+			"(schedule IS NOT NULL AND CASE WHEN LENGTH(schedule) = %d THEN %s ELSE %s END)",
+			len(time_layout),
+			compare_period("schedule", n.Op, time_start, time_end),
+			compare_period("schedule", n.Op, start, end),
+		), nil
+
+	default:
+		column := QUERY_TIME_COLUMNS[n.Field]
+		period, ok := parse_query_date(n.Value, c.now)
+		if !ok {
+			return "", query_error(n.Column, "invalid date '%s'", n.Value)
+		}
+		start := c.arg(period.Start.Unix())
+		end := c.arg(period.End().Unix())
+		comparison := compare_period(column, n.Op, start, end)
+		if column == "created_sec" {
+			return comparison, nil
+		}
+		// Tasks which were never completed or rejected have zero time.
+		return fmt.Sprintf("(%s > 0 AND %s)", column, comparison), nil
+	}
+}
+
+// Compiled `WHERE` and `ORDER BY` clauses with their parameters. The page is
+// cut after the selection, so the hooks are set over the whole result.
+type Task_Filter struct {
+	Where  string
+	Order  string
+	Args   []any
+	Limit  int
+	Offset int
+}
+
+// Page of the selected tasks. The offset past the end gives an empty page.
+func (f *Task_Filter) Page(tasks []*Task) []*Task {
+	start := min(f.Offset, len(tasks))
+	end := len(tasks)
+	if f.Limit > 0 {
+		end = min(start+f.Limit, end)
+	}
+	return tasks[start:end]
+}
+
+func compile_task_query(query *Task_Query, project_ids []int, now time.Time) (*Task_Filter, error) {
+	c := &query_compiler{now: now}
+	conditions := []string{}
+	if !query.Has_State {
+		conditions = append(conditions, fmt.Sprintf("state = %s", c.arg(ACTIVE)))
+	}
+	if !query.Has_Project && project_ids != nil {
+		placeholders := []string{}
+		for _, id := range project_ids {
+			placeholders = append(placeholders, c.arg(id))
+		}
+		conditions = append(conditions, fmt.Sprintf("project_id IN (%s)", strings.Join(placeholders, ", ")))
+	}
+	if query.Where != nil {
+		condition, er := query.Where.compile(c)
+		if er != nil {
+			return nil, er
+		}
+		conditions = append(conditions, condition)
+	}
+
+	filter := &Task_Filter{Args: c.args, Limit: query.Limit, Offset: query.Offset}
+	if len(conditions) > 0 {
+		filter.Where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	keys := query.Sort
+	if len(keys) == 0 {
+		keys = []Query_Sort_Key{{Key: "created"}}
+		if query.State_Sort {
+			keys = []Query_Sort_Key{{Key: "state", Descending: true}, {Key: "created"}}
+		}
+	}
+	// Order of the equal tasks is kept by their ids.
+	if !slices.ContainsFunc(keys, func(key Query_Sort_Key) bool { return key.Key == "id" }) {
+		keys = append(slices.Clone(keys), Query_Sort_Key{Key: "id"})
+	}
+	order := []string{}
+	for _, key := range keys {
+		direction := "ASC"
+		if key.Descending != query.Reverse {
+			direction = "DESC"
+		}
+		column := QUERY_SORT_COLUMNS[key.Key]
+		if column == "schedule" {
+			// Unscheduled tasks go last either way.
+			order = append(order, "schedule IS NULL ASC")
+		}
+		order = append(order, column+" "+direction)
+	}
+	filter.Order = "ORDER BY " + strings.Join(order, ", ")
+	return filter, nil
+}
+
+// Build `WHERE` and `ORDER BY` clauses out of the `show` query. Pass nil
+// project ids to select tasks from every project.
+func build_task_filter(args []string, project_ids []int) (*Task_Filter, error) {
+	query, er := parse_task_query(strings.Join(args, " "))
+	if er != nil {
+		return nil, er
+	}
+	return compile_task_query(query, project_ids, time.Now())
+}
+
+// Log the query error with the mark under the offending column.
+func log_query_error(args []string, er error) {
+	query_er, ok := er.(*Query_Error)
+	if !ok {
+		bone.Log_Error("Invalid query: %s", er)
+		return
+	}
+	input := []rune(strings.Join(args, " "))
+	prefix := input[:min(query_er.Column-1, len(input))]
+	bone.Log_Error(
+		"Invalid query at column %d: %s\n  %s\n  %s^",
+		query_er.Column,
+		query_er.Message,
+		string(input),
+		strings.Repeat(" ", term.Width(string(prefix))),
+	)
+}
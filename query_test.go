@@ -0,0 +1,187 @@
+package main
+
+import (
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var QUERY_TEST_NOW = time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+
+func compile_test_query(t *testing.T, input string, project_ids []int) *Task_Filter {
+	query, er := parse_task_query(input)
+	assert.Nil(t, er)
+	filter, er := compile_task_query(query, project_ids, QUERY_TEST_NOW)
+	assert.Nil(t, er)
+	return filter
+}
+
+func Test_compile_task_query_ok(t *testing.T) {
+	filter := compile_test_query(t, "", []int{1})
+	assert.Equal(t, "WHERE state = $1 AND project_id IN ($2)", filter.Where)
+	assert.Equal(t, "ORDER BY created_sec ASC, id ASC", filter.Order)
+	assert.Equal(t, []any{ACTIVE, 1}, filter.Args)
+
+	filter = compile_test_query(t, "state:active prio>=1 tag:x project:work sort:-prio,created", []int{1})
+	assert.Equal(
+		t,
+		"WHERE (state = $1 AND completion_priority >= $2 AND id IN (SELECT task_id FROM task_tag WHERE name = $3) AND project_id IN (SELECT id FROM project WHERE title = $4))",
+		filter.Where,
+	)
+	assert.Equal(t, "ORDER BY completion_priority DESC, created_sec ASC, id ASC", filter.Order)
+	assert.Equal(t, []any{ACTIVE, 1, "x", "work"}, filter.Args)
+
+	filter = compile_test_query(t, "created>2026-01-01 sched<+7d", nil)
+	assert.Equal(
+		t,
+		"WHERE state = $1 AND (created_sec >= $3 AND (schedule IS NOT NULL AND CASE WHEN LENGTH(schedule) = 19 THEN schedule < $6 ELSE schedule < $4 END))",
+		filter.Where,
+	)
+	assert.Equal(t, "2026-03-17", filter.Args[3])
+	assert.Equal(t, "2026-03-17 00:00:00", filter.Args[5])
+	assert.Equal(t, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC).Unix(), filter.Args[2])
+
+	filter = compile_test_query(t, "(prio:today or sched:none) not tag:x", nil)
+	assert.Equal(
+		t,
+		"WHERE state = $1 AND ((completion_priority = $2 OR schedule IS NULL) AND NOT id IN (SELECT task_id FROM task_tag WHERE name = $3))",
+		filter.Where,
+	)
+	assert.Equal(t, []any{ACTIVE, TODAY_PRIORITY, "x"}, filter.Args)
+
+	// Only the terms every task has to match replace the default filters.
+	filter = compile_test_query(t, "state:completed or not project:work", []int{1})
+	assert.Equal(
+		t,
+		"WHERE state = $1 AND project_id IN ($2) AND (state = $3 OR NOT project_id IN (SELECT id FROM project WHERE title = $4))",
+		filter.Where,
+	)
+	filter = compile_test_query(t, "(state:completed project:work) tag:x", []int{1})
+	assert.Equal(
+		t,
+		"WHERE ((state = $1 AND project_id IN (SELECT id FROM project WHERE title = $2)) AND id IN (SELECT task_id FROM task_tag WHERE name = $3))",
+		filter.Where,
+	)
+
+	filter = compile_test_query(t, "completed:2026-02", nil)
+	assert.Equal(t, "WHERE state = $1 AND (last_completed_sec > 0 AND (last_completed_sec >= $2 AND last_completed_sec < $3))", filter.Where)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC).Unix(), filter.Args[2])
+
+	filter = compile_test_query(t, "Buy 50%", nil)
+	assert.Equal(t, []any{ACTIVE, "%buy%", `%50\%%`}, filter.Args)
+}
+
+func Test_compile_task_query_local_ok(t *testing.T) {
+	// Past midnight east of UTC, the local day is ahead of the UTC one.
+	now := time.Date(2026, 3, 10, 1, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+	query, er := parse_task_query("created:today sched:today")
+	assert.Nil(t, er)
+	filter, er := compile_task_query(query, nil, now)
+	assert.Nil(t, er)
+	assert.Equal(
+		t,
+		[]any{
+			ACTIVE,
+			time.Date(2026, 3, 9, 21, 0, 0, 0, time.UTC).Unix(),
+			time.Date(2026, 3, 10, 21, 0, 0, 0, time.UTC).Unix(),
+			"2026-03-10",
+			"2026-03-11",
+			"2026-03-09 21:00:00",
+			"2026-03-10 21:00:00",
+		},
+		filter.Args,
+	)
+}
+
+func Test_compile_task_query_shorthands_ok(t *testing.T) {
+	filter := compile_test_query(t, "-a -reverse", []int{1})
+	assert.Equal(t, "WHERE project_id IN ($1) AND 1 = 1", filter.Where)
+	assert.Equal(t, "ORDER BY state ASC, created_sec DESC, id DESC", filter.Order)
+
+	filter = compile_test_query(t, "-c -ocompleted -reverse -scompleted", []int{1})
+	assert.Equal(t, "WHERE project_id IN ($1) AND state = $2", filter.Where)
+	assert.Equal(t, "ORDER BY last_completed_sec DESC, id DESC", filter.Order)
+	assert.Equal(t, []any{1, COMPLETED}, filter.Args)
+}
+
+func Test_task_filter_page_ok(t *testing.T) {
+	tasks := []*Task{{Id: 1}, {Id: 2}, {Id: 3}}
+	assert.Len(t, (&Task_Filter{}).Page(tasks), 3)
+	assert.Equal(t, []*Task{{Id: 2}}, (&Task_Filter{Offset: 1, Limit: 1}).Page(tasks))
+	assert.Empty(t, (&Task_Filter{Offset: 5, Limit: 1}).Page(tasks))
+}
+
+func Test_parse_task_query_error(t *testing.T) {
+	cases := map[string]int{
+		"prio>=":            7,
+		"nosuch:1":          1,
+		"tag>x":             4,
+		"-x":                1,
+		"a or":              5,
+		"or a":              1,
+		"(a":                3,
+		"a)":                2,
+		"()":                1,
+		"not":               4,
+		"(sort:prio)":       2,
+		"sort:prio,nosuch":  11,
+		"state:active -c -": 17,
+		"-limit":            7,
+		"-limit 0":          8,
+		"-offset x":         9,
+		"(-limit 1)":        2,
+	}
+	for input, column := range cases {
+		_, er := parse_task_query(input)
+		if assert.IsType(t, &Query_Error{}, er, input) {
+			assert.Equal(t, column, er.(*Query_Error).Column, input)
+		}
+	}
+
+	for input, column := range map[string]int{"state:done": 7, "created<2026-13": 9, "sched>=soon": 8} {
+		query, er := parse_task_query(input)
+		assert.Nil(t, er)
+		_, er = compile_task_query(query, nil, QUERY_TEST_NOW)
+		if assert.IsType(t, &Query_Error{}, er, input) {
+			assert.Equal(t, column, er.(*Query_Error).Column, input)
+		}
+	}
+}
+
+func Test_show_query_ok(t *testing.T) {
+	project_id, output := with_project_output(t, "query", "plain")
+	tx := db.Begin()
+	other_id, er := insert_project(tx, "query_other")
+	assert.Nil(t, er)
+	task_id, er := insert_task(tx, "Urgent", project_id)
+	assert.Nil(t, er)
+	assert.Nil(t, set_task_priority(tx, task_id, TODAY_PRIORITY))
+	_, er = insert_task(tx, "Later", project_id)
+	assert.Nil(t, er)
+	_, er = insert_task(tx, "Elsewhere", other_id)
+	assert.Nil(t, er)
+	assert.Nil(t, tx.Commit())
+
+	// `-a` keeps the project filter.
+	assert.Equal(t, common.OK, process_input("s -a"))
+	assert.Equal(t, "|1| . Urgent\n|2| . Later\n", output.String())
+
+	// Project term inside 'or' keeps the project filter too.
+	output.Reset()
+	assert.Equal(t, common.OK, process_input("s (prio>=week project:query) or project:query_other sort:title"))
+	assert.Equal(t, "|1| . Urgent\n", output.String())
+
+	output.Reset()
+	assert.Equal(t, common.OK, process_input("s project:* ((prio>=week project:query) or project:query_other) sort:title"))
+	assert.Equal(t, "|1| . Elsewhere\n|2| . Urgent\n", output.String())
+
+	output.Reset()
+	assert.Equal(t, common.OK, process_input("s -a -offset 1 -limit 5"))
+	assert.Equal(t, "|2| . Later\n", output.String())
+	assert.Equal(t, "Later", hooks[1].(*Task).Title)
+
+	assert.Equal(t, common.INPUT_ERROR, process_input("s prio>="))
+}
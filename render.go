@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/term"
+)
+
+// Command handlers don't print by themselves, they emit structured results,
+// which are rendered by the active renderer:
+//   - `terminal` (default): colored output for the interactive use
+//   - `plain`: the same without escape sequences and emoji
+//   - `json`: one JSON object per line, for scripts
+//
+// The renderer is selected by the `--format` flag or by the `format` command
+// in the REPL.
+//
+// Text lists are laid out in columns by the display width, titles are cut to
+// the terminal width, and lists taller than the terminal are piped to
+// `$PAGER`.
+
+type Message_Result struct {
+	Text string
+}
+
+type Prompt_Result struct {
+	Text string
+}
+
+// Which time column to show along with the tasks.
+const (
+	TIME_COLUMN_NONE = iota
+	TIME_COLUMN_CREATED
+	TIME_COLUMN_COMPLETED
+	TIME_COLUMN_REJECTED
+)
+
+// Tasks are listed in the order of their hooks. Project titles by their ids
+// are set, if tasks come from several projects. For a page of the result,
+// offset is the number of the tasks before it.
+type Task_List_Result struct {
+	Tasks          []*Task
+	Time_Column    int
+	Project_Titles map[int]string
+	Offset         int
+}
+
+type Project_List_Result struct {
+	Projects []*Project
+}
+
+type Renderer interface {
+	Render(result any)
+	// Line printed before the REPL input.
+	Repl_Prompt(project_name string, prompted bool) string
+}
+
+var RENDERERS = map[string]func(w io.Writer) Renderer{
+	"terminal": func(w io.Writer) Renderer { return &Terminal_Renderer{w: w} },
+	"plain":    func(w io.Writer) Renderer { return &Plain_Renderer{w: w} },
+	"json":     func(w io.Writer) Renderer { return &Json_Renderer{w: w} },
+}
+
+var renderer Renderer = &Terminal_Renderer{w: os.Stdout}
+var renderer_name = "terminal"
+
+func set_renderer(name string) bool {
+	create, ok := RENDERERS[name]
+	if !ok {
+		return false
+	}
+	renderer = create(os.Stdout)
+	renderer_name = name
+	return true
+}
+
+func emit(result any) {
+	renderer.Render(result)
+}
+
+func emit_message(message string, args ...any) {
+	emit(&Message_Result{Text: fmt.Sprintf(message, args...)})
+}
+
+func task_time(t *Task, time_column int) int {
+	switch time_column {
+	case TIME_COLUMN_CREATED:
+		return t.Created_Sec
+	case TIME_COLUMN_COMPLETED:
+		return t.Last_Completed_Sec
+	case TIME_COLUMN_REJECTED:
+		return t.Last_Rejected_Sec
+	default:
+		return 0
+	}
+}
+
+type Terminal_Renderer struct {
+	w io.Writer
+}
+
+func (r *Terminal_Renderer) Render(result any) {
+	render_paged(r.w, func(w io.Writer, columns int) {
+		render_text(w, result, columns, theme, func(t *Task) string { return t.Get_Completion_Mark() })
+	})
+}
+
+func (r *Terminal_Renderer) Repl_Prompt(project_name string, prompted bool) string {
+	final_sign := ">"
+	if prompted {
+		final_sign = "?"
+	}
+	return fmt.Sprintf(
+		"%s%s ",
+		bone.Paint("("+project_name+")", theme.Prompt_Project_Color),
+		bone.Paint(final_sign, theme.Prompt_Sign_Color),
+	)
+}
+
+type Plain_Renderer struct {
+	w io.Writer
+}
+
+func plain_completion_mark(t *Task) string {
+	switch t.State {
+	case COMPLETED:
+		return "+"
+	case REJECTED:
+		return "-"
+	default:
+		return "."
+	}
+}
+
+// Charts and cards of the plain output are drawn by the ASCII theme without
+// colors.
+var plain_theme, _ = build_theme(map[string]string{}, false, true)
+
+func (r *Plain_Renderer) Render(result any) {
+	render_paged(r.w, func(w io.Writer, columns int) {
+		render_text(w, result, columns, plain_theme, plain_completion_mark)
+	})
+}
+
+func (r *Plain_Renderer) Repl_Prompt(project_name string, prompted bool) string {
+	final_sign := ">"
+	if prompted {
+		final_sign = "?"
+	}
+	return fmt.Sprintf("(%s)%s ", project_name, final_sign)
+}
+
+// Size of the terminal the text is written to, zeros if it's not a terminal.
+func output_size(w io.Writer) (int, int) {
+	f, ok := w.(*os.File)
+	if !ok || !is_terminal(f) {
+		return 0, 0
+	}
+	columns, rows, er := term.Size(f)
+	if er != nil {
+		return term.DEFAULT_COLUMNS, term.DEFAULT_ROWS
+	}
+	return columns, rows
+}
+
+// Render the text, piping it to the pager, if it doesn't fit the terminal
+// along with the prompt line.
+func render_paged(w io.Writer, render func(w io.Writer, columns int)) {
+	columns, rows := output_size(w)
+	if rows == 0 {
+		render(w, columns)
+		return
+	}
+	var b bytes.Buffer
+	render(&b, columns)
+	if strings.Count(b.String(), "\n") >= rows && run_pager(b.String()) {
+		return
+	}
+	w.Write(b.Bytes())
+}
+
+// Pipe the text to `$PAGER`, `less` by default. Returns false, if the pager
+// cannot be started, so the text should be written as is.
+func run_pager(text string) bool {
+	command := strings.Fields(os.Getenv("PAGER"))
+	if len(command) == 0 {
+		command = []string{"less"}
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		// Keep the colors, and quit if the text fits the screen after all.
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	return cmd.Run() == nil
+}
+
+// Lay out the cells in columns by their display width. The last column is cut
+// to fit the line, unless the line width is zero.
+func write_table(w io.Writer, rows [][]string, line_width int) {
+	widths := []int{}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], term.Width(cell))
+		}
+	}
+	for _, row := range rows {
+		line := ""
+		for i, cell := range row[:len(row)-1] {
+			line += term.Pad(cell, widths[i]) + " "
+		}
+		last := row[len(row)-1]
+		if line_width > 0 {
+			last = term.Truncate(last, line_width-term.Width(line))
+		}
+		fmt.Fprintln(w, line+last)
+	}
+}
+
+// Text rendering shared by the terminal and plain renderers, which differ only
+// by the theme and the marks. Zero columns don't cut the lines.
+func render_text(w io.Writer, result any, columns int, theme *Theme, completion_mark func(t *Task) string) {
+	switch r := result.(type) {
+	case *Message_Result:
+		fmt.Fprintln(w, r.Text)
+	case *Prompt_Result:
+		fmt.Fprintln(w, r.Text+" [Y/N]")
+	case *Task_List_Result:
+		if len(r.Tasks) == 0 {
+			fmt.Fprint(w, "No tasks\n")
+		}
+		rows := [][]string{}
+		for i, t := range r.Tasks {
+			row := []string{fmt.Sprintf("|%d|", r.Offset+i+1), completion_mark(t)}
+			if r.Time_Column != TIME_COLUMN_NONE {
+				row = append(row, fmt.Sprintf("|%s|", convert_sec_to_str(task_time(t, r.Time_Column))))
+			}
+			if r.Project_Titles != nil {
+				row = append(row, fmt.Sprintf("[%s]", r.Project_Titles[t.Project_Id]))
+			}
+			rows = append(rows, append(row, t.Title))
+		}
+		write_table(w, rows, columns)
+	case *Review_Result:
+		write_review_text(w, r)
+	case *Chart_Result:
+		draw_chart(w, r, columns, theme)
+	case *Heatmap_Result:
+		draw_heatmap(w, r, columns, theme)
+	case *Board_Result:
+		draw_board(w, r, columns, theme)
+	case *Calendar_Result:
+		draw_calendar(w, r, columns, theme, completion_mark)
+	case *Agenda_Result:
+		draw_agenda(w, r, columns, theme)
+	case *Next_Result:
+		draw_next(w, r, columns, theme, completion_mark)
+	case *Project_List_Result:
+		if len(r.Projects) == 0 {
+			// This shouldn't be possible.
+			fmt.Fprint(w, "No projects?\n")
+		}
+		rows := [][]string{}
+		for i, p := range r.Projects {
+			rows = append(rows, []string{fmt.Sprintf("|%d|", i+1), p.Title})
+		}
+		write_table(w, rows, columns)
+	default:
+		fmt.Fprintf(w, "%v\n", r)
+	}
+}
+
+type Json_Renderer struct {
+	w io.Writer
+}
+
+type Json_Task struct {
+	Type               string  `json:"type"`
+	Hook               int     `json:"hook"`
+	Id                 int     `json:"id"`
+	Title              string  `json:"title"`
+	State              string  `json:"state"`
+	Priority           int     `json:"priority"`
+	Schedule           *string `json:"schedule"`
+	Created_Sec        int     `json:"created_sec"`
+	Last_Completed_Sec int     `json:"last_completed_sec"`
+	Last_Rejected_Sec  int     `json:"last_rejected_sec"`
+	Project_Id         int     `json:"project_id"`
+	Effort_Sec         *int    `json:"effort_sec"`
+	Project            string  `json:"project,omitempty"`
+	// State of the board column, the calendar list, or the agenda section.
+	Column string `json:"column,omitempty"`
+}
+
+type Json_Project struct {
+	Type  string `json:"type"`
+	Hook  int    `json:"hook"`
+	Id    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+type Json_Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func to_json_task(t *Task, hook int) *Json_Task {
+	return &Json_Task{
+		Type:               "task",
+		Hook:               hook,
+		Id:                 t.Id,
+		Title:              t.Title,
+		State:              state_name(t.State),
+		Priority:           t.Priority,
+		Schedule:           t.Schedule,
+		Created_Sec:        t.Created_Sec,
+		Last_Completed_Sec: t.Last_Completed_Sec,
+		Last_Rejected_Sec:  t.Last_Rejected_Sec,
+		Project_Id:         t.Project_Id,
+		Effort_Sec:         t.Effort_Sec,
+	}
+}
+
+// Lists are rendered as one line per item, so they can be streamed.
+func (r *Json_Renderer) Render(result any) {
+	encoder := json.NewEncoder(r.w)
+	switch result := result.(type) {
+	case *Message_Result:
+		encoder.Encode(&Json_Text{Type: "message", Text: result.Text})
+	case *Prompt_Result:
+		encoder.Encode(&Json_Text{Type: "prompt", Text: result.Text})
+	case *Task_List_Result:
+		for i, t := range result.Tasks {
+			json_task := to_json_task(t, result.Offset+i+1)
+			json_task.Project = result.Project_Titles[t.Project_Id]
+			encoder.Encode(json_task)
+		}
+	case *Calendar_Result:
+		encoder.Encode(result)
+		for i, t := range append(slices.Clone(result.Day_Tasks), result.Month_Tasks...) {
+			json_task := to_json_task(t, i+1)
+			json_task.Column = "day"
+			if i >= len(result.Day_Tasks) {
+				json_task.Column = "month"
+			}
+			encoder.Encode(json_task)
+		}
+	case *Next_Result:
+		for i, s := range result.Scores {
+			encoder.Encode(&Json_Scored_Task{Json_Task: to_json_task(s.Task, i+1), Score: s.Score, Parts: s.Parts})
+		}
+	case *Agenda_Result:
+		for _, json_task := range agenda_json_tasks(result) {
+			encoder.Encode(json_task)
+		}
+	case *Board_Result:
+		hook := 1
+		for _, c := range result.Columns {
+			for _, t := range c.Tasks {
+				json_task := to_json_task(t, hook)
+				json_task.Column = state_name(c.State)
+				encoder.Encode(json_task)
+				hook++
+			}
+		}
+	case *Project_List_Result:
+		for i, p := range result.Projects {
+			encoder.Encode(&Json_Project{Type: "project", Hook: i + 1, Id: p.Id, Title: p.Title})
+		}
+	default:
+		encoder.Encode(result)
+	}
+}
+
+func (r *Json_Renderer) Repl_Prompt(project_name string, prompted bool) string {
+	return ""
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_write_table_ok(t *testing.T) {
+	var output bytes.Buffer
+	write_table(&output, [][]string{
+		{"|1|", "🔴", "Short"},
+		{"|10|", ".", "Rather long title"},
+	}, 16)
+	assert.Equal(t, "|1|  🔴 Short\n|10| .  Rather …\n", output.String())
+
+	output.Reset()
+	write_table(&output, [][]string{{"|1|", "Rather long title"}}, 0)
+	assert.Equal(t, "|1| Rather long title\n", output.String())
+}
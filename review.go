@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"time"
+)
+
+// Review of the work done in the period: tasks completed and rejected in it,
+// grouped by project and by day, tasks created in it and still open, and
+// tasks active longer than the threshold, `review.stale_days` of user.cfg, 30
+// days by default. Days are local.
+
+const DAY_SEC = 24 * 60 * 60
+
+var review_duration_regex = regexp.MustCompile(`^(\d+)([dwmy])$`)
+
+type Review_Task struct {
+	Id    int    `json:"id"`
+	Title string `json:"title"`
+	// Day of the completion, rejection or creation.
+	Day string `json:"day"`
+}
+
+type Review_Project struct {
+	Title string         `json:"project"`
+	Tasks []*Review_Task `json:"tasks"`
+}
+
+type Review_Section struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	// Tasks of the project are listed under the day headings.
+	By_Day   bool              `json:"-"`
+	Projects []*Review_Project `json:"projects"`
+}
+
+func (s *Review_Section) Count() int {
+	count := 0
+	for _, p := range s.Projects {
+		count += len(p.Tasks)
+	}
+	return count
+}
+
+type Review_Result struct {
+	Type string `json:"type"`
+	// Inclusive.
+	Start    string            `json:"start"`
+	End      string            `json:"end"`
+	Sections []*Review_Section `json:"sections"`
+}
+
+// Period of the review: the last days, weeks, months or years up to today,
+// like `2w`, or a date of the `show` query, like `2026-10` or `yesterday`.
+func parse_review_period(value string, now time.Time) (time.Time, time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	match := review_duration_regex.FindStringSubmatch(value)
+	if match != nil {
+		n, er := strconv.Atoi(match[1])
+		if er != nil || n == 0 {
+			return time.Time{}, time.Time{}, false
+		}
+		return add_date_units(today, -n, match[2]).AddDate(0, 0, 1), today.AddDate(0, 0, 1), true
+	}
+	period, ok := parse_query_date(value, now)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	return period.Start, period.End(), true
+}
+
+// Group the tasks by the project titles, keeping the order of the tasks
+// within the project.
+func group_review_tasks(tasks []*Task, project_titles map[int]string, task_sec func(t *Task) int) []*Review_Project {
+	projects := []*Review_Project{}
+	by_title := map[string]*Review_Project{}
+	for _, t := range tasks {
+		title := project_titles[t.Project_Id]
+		project, ok := by_title[title]
+		if !ok {
+			project = &Review_Project{Title: title}
+			by_title[title] = project
+			projects = append(projects, project)
+		}
+		project.Tasks = append(project.Tasks, &Review_Task{
+			Id:    t.Id,
+			Title: t.Title,
+			Day:   bone.Date_Sec(task_sec(t), "2006-01-02"),
+		})
+	}
+	return projects
+}
+
+// Review the period.
+//
+// Args:
+//   - 1 (default="1w"): period, e.g. `2w`, `3d`, `2026-10`, `yesterday`
+//   - `-stale DAYS`: list active tasks older than the days
+//   - `-everything`: review every project instead of the current one
+//   - `-md`: print the report as Markdown
+//   - `-o PATH`: write the Markdown report to the file
+func review(ctx *Command_Context) int {
+	period := "1w"
+	if len(ctx.Args) > 0 && ctx.Args[0][0] != '-' {
+		period = ctx.Args[0]
+	}
+	now := time.Now()
+	start, end, ok := parse_review_period(period, now)
+	if !ok {
+		bone.Log_Error("Invalid period '%s', expected e.g. `2w` or `2026-10`.", period)
+		return common.INPUT_ERROR
+	}
+	stale_days := bone.Config.Get_Int("review", "stale_days", 30)
+	if value, ok := ctx.Get_Arg_Value("-stale"); ok {
+		days, er := strconv.Atoi(value)
+		if er != nil || days < 0 {
+			bone.Log_Error("Expected number of days after `-stale`, got '%s'.", value)
+			return common.INPUT_ERROR
+		}
+		stale_days = days
+	}
+
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	project_filter := ""
+	if !ctx.Has_Arg("-everything") {
+		project_filter = fmt.Sprintf(" AND project_id = %d", current_project_id)
+	}
+	project_titles, er := get_project_titles(tx)
+	if er != nil {
+		bone.Log_Error("During project selection, an error occured: %s", er)
+		return common.SELECT_ERROR
+	}
+	// Projects go in the order of their titles.
+	order := " ORDER BY (SELECT title FROM project WHERE project.id = project_id) ASC, "
+
+	sections := []struct {
+		section *Review_Section
+		query   string
+		args    []any
+		sec     func(t *Task) int
+	}{
+		{
+			&Review_Section{Name: "completed", Title: "Completed", By_Day: true},
+			"SELECT * FROM task WHERE state = $1 AND last_completed_sec >= $2 AND last_completed_sec < $3" +
+				project_filter + order + "last_completed_sec ASC",
+			[]any{COMPLETED, start.Unix(), end.Unix()},
+			func(t *Task) int { return t.Last_Completed_Sec },
+		},
+		{
+			&Review_Section{Name: "rejected", Title: "Rejected", By_Day: true},
+			"SELECT * FROM task WHERE state = $1 AND last_rejected_sec >= $2 AND last_rejected_sec < $3" +
+				project_filter + order + "last_rejected_sec ASC",
+			[]any{REJECTED, start.Unix(), end.Unix()},
+			func(t *Task) int { return t.Last_Rejected_Sec },
+		},
+		{
+			&Review_Section{Name: "open", Title: "Created and still open"},
+			"SELECT * FROM task WHERE state = $1 AND created_sec >= $2 AND created_sec < $3" +
+				project_filter + order + "created_sec ASC",
+			[]any{ACTIVE, start.Unix(), end.Unix()},
+			func(t *Task) int { return t.Created_Sec },
+		},
+		{
+			&Review_Section{Name: "stale", Title: fmt.Sprintf("Active longer than %d days", stale_days)},
+			"SELECT * FROM task WHERE state = $1 AND created_sec < $2" +
+				project_filter + order + "created_sec ASC",
+			[]any{ACTIVE, now.Unix() - int64(stale_days*DAY_SEC)},
+			func(t *Task) int { return t.Created_Sec },
+		},
+	}
+
+	result := &Review_Result{
+		Type:  "review",
+		Start: start.Format("2006-01-02"),
+		End:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+	}
+	for _, s := range sections {
+		tasks := []*Task{}
+		er := tx.Select(&tasks, s.query, s.args...)
+		if er != nil {
+			bone.Log_Error("During task selection, an error occured: %s", er)
+			return common.SELECT_ERROR
+		}
+		s.section.Projects = group_review_tasks(tasks, project_titles, s.sec)
+		result.Sections = append(result.Sections, s.section)
+	}
+
+	if path, ok := ctx.Get_Arg_Value("-o"); ok {
+		return write_review_file(path, result)
+	}
+	if ctx.Has_Arg("-md") {
+		write_review_markdown(os.Stdout, result)
+		return common.OK
+	}
+	emit(result)
+	return common.OK
+}
+
+func write_review_file(path string, result *Review_Result) int {
+	f, er := os.Create(path)
+	if er != nil {
+		bone.Log_Error("Cannot create file '%s', error: %s", path, er)
+		return common.FILE_ERROR
+	}
+	defer f.Close()
+	er = write_review_markdown(f, result)
+	if er != nil {
+		bone.Log_Error("During Markdown writing, an error occured: %s", er)
+		return common.FILE_ERROR
+	}
+	emit_message("Review is written to '%s'.", path)
+	return common.OK
+}
+
+func write_review_text(w io.Writer, result *Review_Result) {
+	fmt.Fprintf(w, "Review %s - %s\n", result.Start, result.End)
+	for _, section := range result.Sections {
+		fmt.Fprintf(w, "\n%s (%d)\n", section.Title, section.Count())
+		for _, p := range section.Projects {
+			fmt.Fprintf(w, "  %s\n", p.Title)
+			day := ""
+			for _, t := range p.Tasks {
+				if !section.By_Day {
+					fmt.Fprintf(w, "    %s %s\n", t.Day, t.Title)
+					continue
+				}
+				if t.Day != day {
+					day = t.Day
+					fmt.Fprintf(w, "    %s\n", day)
+				}
+				fmt.Fprintf(w, "      %s\n", t.Title)
+			}
+		}
+	}
+}
+
+// Sections are the second level headings, so the report can be pasted under
+// the heading of the notes.
+func write_review_markdown(w io.Writer, result *Review_Result) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Review %s – %s\n", result.Start, result.End)
+	for _, section := range result.Sections {
+		fmt.Fprintf(&b, "\n## %s (%d)\n", section.Title, section.Count())
+		for _, p := range section.Projects {
+			fmt.Fprintf(&b, "\n### %s\n\n", p.Title)
+			day := ""
+			for _, t := range p.Tasks {
+				if !section.By_Day {
+					fmt.Fprintf(&b, "- %s (%s)\n", t.Title, t.Day)
+					continue
+				}
+				if t.Day != day {
+					day = t.Day
+					fmt.Fprintf(&b, "- %s\n", day)
+				}
+				fmt.Fprintf(&b, "  - %s\n", t.Title)
+			}
+		}
+	}
+	_, er := io.WriteString(w, b.String())
+	return er
+}
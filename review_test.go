@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parse_review_period_ok(t *testing.T) {
+	now := time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC)
+	start, end, ok := parse_review_period("1w", now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), end)
+
+	start, end, ok = parse_review_period("2026-09", now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), end)
+
+	_, _, ok = parse_review_period("0d", now)
+	assert.False(t, ok)
+	_, _, ok = parse_review_period("lately", now)
+	assert.False(t, ok)
+}
+
+func Test_review_ok(t *testing.T) {
+	now := time.Now()
+	day := func(days_ago int) int64 {
+		return time.Date(now.Year(), now.Month(), now.Day()-days_ago, 12, 0, 0, 0, time.Local).Unix()
+	}
+	project_id, _ := with_project_output(t, "review", "plain")
+	tx := db.Begin()
+	tasks := []struct {
+		title     string
+		state     int
+		created   int64
+		completed int64
+	}{
+		{"Done today", COMPLETED, day(3), day(0)},
+		{"Done earlier", COMPLETED, day(3), day(2)},
+		{"Done long ago", COMPLETED, day(40), day(20)},
+		{"Dropped", REJECTED, day(3), 0},
+		{"Fresh", ACTIVE, day(1), 0},
+		{"Forgotten", ACTIVE, day(40), 0},
+	}
+	for _, task := range tasks {
+		id, er := insert_task(tx, task.title, project_id)
+		assert.Nil(t, er)
+		_, er = tx.Exec(
+			"UPDATE task SET state = $1, created_sec = $2, last_completed_sec = $3, last_rejected_sec = $4 WHERE id = $5",
+			task.state, task.created, task.completed, day(1), id,
+		)
+		assert.Nil(t, er)
+	}
+	assert.Nil(t, tx.Commit())
+
+	path := filepath.Join(t.TempDir(), "review.md")
+	assert.Equal(t, common.OK, process_input("review 1w -stale 30 -o "+path))
+	data, er := os.ReadFile(path)
+	assert.Nil(t, er)
+	date := func(days_ago int) string {
+		return time.Unix(day(days_ago), 0).Format("2006-01-02")
+	}
+	expected := "# Review " + date(6) + " – " + date(0) + "\n" +
+		"\n## Completed (2)\n\n### review\n\n" +
+		"- " + date(2) + "\n  - Done earlier\n" +
+		"- " + date(0) + "\n  - Done today\n" +
+		"\n## Rejected (1)\n\n### review\n\n" +
+		"- " + date(1) + "\n  - Dropped\n" +
+		"\n## Created and still open (1)\n\n### review\n\n" +
+		"- Fresh (" + date(1) + ")\n" +
+		"\n## Active longer than 30 days (1)\n\n### review\n\n" +
+		"- Forgotten (" + date(40) + ")\n"
+	assert.Equal(t, expected, string(data))
+
+	assert.Equal(t, common.INPUT_ERROR, process_input("review lately"))
+	assert.Equal(t, common.INPUT_ERROR, process_input("review -stale x"))
+}
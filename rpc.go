@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"tasker/internal/common"
+	"tasker/internal/db"
+)
+
+// JSON-RPC 2.0 over stdin and stdout, started by `tasker --rpc`, for editor
+// integrations. Messages are read one after another, each response is written
+// on its own line. Batches are supported.
+//
+// Methods mirror the commands, but tasks are addressed by their ids instead
+// of hooks, and are represented the same way as by the JSON export:
+//   - `listProjects`
+//   - `switchProject` with `{"project"}`
+//   - `listTasks` with `{"project", "filter"}`, see `GET /tasks` of the API
+//   - `addTask` with `{"title", "project", "priority", "schedule"}`
+//   - `updateTask` with `{"id", ...}`, see `PATCH /tasks/{id}` of the API
+//   - `completeTask`, `rejectTask`, `deleteTask` with `{"id"}`
+//   - `moveTask` with `{"id", "project"}`
+//
+// Errors of the methods are mapped from `common` codes: input errors become
+// "Invalid params", the rest are server errors `-32000 - CODE`. The original
+// code is always passed as `data.code`.
+
+const (
+	RPC_PARSE_ERROR      = -32700
+	RPC_INVALID_REQUEST  = -32600
+	RPC_METHOD_NOT_FOUND = -32601
+	RPC_INVALID_PARAMS   = -32602
+	RPC_SERVER_ERROR     = -32000
+)
+
+type Rpc_Request struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type Rpc_Error_Data struct {
+	Code int `json:"code"`
+}
+
+type Rpc_Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    *Rpc_Error_Data `json:"data,omitempty"`
+}
+
+type Rpc_Response struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Rpc_Error      `json:"error,omitempty"`
+}
+
+type Rpc_Task_Params struct {
+	Id int `json:"id"`
+	Api_Task_Input
+}
+
+type Rpc_Project_Params struct {
+	Project string `json:"project"`
+}
+
+type Rpc_List_Tasks_Params struct {
+	Project string `json:"project"`
+	Filter  string `json:"filter"`
+}
+
+type rpc_method func(tx *db.Tx, params json.RawMessage) (any, int, string)
+
+var RPC_METHODS = map[string]rpc_method{
+	"listProjects":  rpc_list_projects,
+	"switchProject": rpc_switch_project,
+	"listTasks":     rpc_list_tasks,
+	"addTask":       rpc_add_task,
+	"updateTask":    rpc_update_task,
+	"completeTask":  rpc_complete_task,
+	"rejectTask":    rpc_reject_task,
+	"deleteTask":    rpc_delete_task,
+	"moveTask":      rpc_move_task,
+}
+
+func rpc_error_code(code int) int {
+	switch code {
+	case common.INPUT_ERROR, common.CONVERSION_ERROR:
+		return RPC_INVALID_PARAMS
+	default:
+		return RPC_SERVER_ERROR - code
+	}
+}
+
+// Absent params are treated as an empty object.
+func rpc_decode_params(params json.RawMessage, target any) (int, string) {
+	if len(params) == 0 || string(params) == "null" {
+		return common.OK, ""
+	}
+	er := json.Unmarshal(params, target)
+	if er != nil {
+		return common.INPUT_ERROR, er.Error()
+	}
+	return common.OK, ""
+}
+
+func rpc_list_projects(tx *db.Tx, params json.RawMessage) (any, int, string) {
+	projects := []*Project{}
+	er := tx.Select(&projects, "SELECT * FROM project ORDER BY id ASC")
+	if er != nil {
+		return nil, common.SELECT_ERROR, er.Error()
+	}
+	return projects, common.OK, ""
+}
+
+func rpc_switch_project(tx *db.Tx, params json.RawMessage) (any, int, string) {
+	var p Rpc_Project_Params
+	code, message := rpc_decode_params(params, &p)
+	if code != common.OK {
+		return nil, code, message
+	}
+	project, er := get_project(tx, p.Project)
+	if er != nil {
+		return nil, common.NO_SUCH_PROJECT, fmt.Sprintf("no project '%s'", p.Project)
+	}
+	current_project_id = project.Id
+	current_project_name = project.Title
+	return project, common.OK, ""
+}
+
+func rpc_list_tasks(tx *db.Tx, params json.RawMessage) (any, int, string) {
+	var p Rpc_List_Tasks_Params
+	code, message := rpc_decode_params(params, &p)
+	if code != common.OK {
+		return nil, code, message
+	}
+	return api_query_tasks(tx, p.Project, p.Filter)
+}
+
+func rpc_add_task(tx *db.Tx, params json.RawMessage) (any, int, string) {
+	var input Api_Task_Input
+	code, message := rpc_decode_params(params, &input)
+	if code != common.OK {
+		return nil, code, message
+	}
+	return api_create_task_input(tx, &input)
+}
+
+func rpc_update_task(tx *db.Tx, params json.RawMessage) (any, int, string) {
+	var p Rpc_Task_Params
+	code, message := rpc_decode_params(params, &p)
+	if code != common.OK {
+		return nil, code, message
+	}
+	return api_update_task_input(tx, p.Id, &p.Api_Task_Input)
+}
+
+func rpc_set_task_state(tx *db.Tx, params json.RawMessage, state int) (any, int, string) {
+	var p Rpc_Task_Params
+	code, message := rpc_decode_params(params, &p)
+	if code != common.OK {
+		return nil, code, message
+	}
+	name := state_name(state)
+	return api_update_task_input(tx, p.Id, &Api_Task_Input{State: &name})
+}
+
+func rpc_complete_task(tx *db.Tx, params json.RawMessage) (any, int, string) {
+	return rpc_set_task_state(tx, params, COMPLETED)
+}
+
+func rpc_reject_task(tx *db.Tx, params json.RawMessage) (any, int, string) {
+	return rpc_set_task_state(tx, params, REJECTED)
+}
+
+func rpc_delete_task(tx *db.Tx, params json.RawMessage) (any, int, string) {
+	var p Rpc_Task_Params
+	code, message := rpc_decode_params(params, &p)
+	if code != common.OK {
+		return nil, code, message
+	}
+	_, code, message = api_delete_task_id(tx, p.Id)
+	return map[string]int{"id": p.Id}, code, message
+}
+
+func rpc_move_task(tx *db.Tx, params json.RawMessage) (any, int, string) {
+	var p Rpc_Task_Params
+	code, message := rpc_decode_params(params, &p)
+	if code != common.OK {
+		return nil, code, message
+	}
+	if p.Project == nil {
+		return nil, common.INPUT_ERROR, "missing project"
+	}
+	return api_update_task_input(tx, p.Id, &Api_Task_Input{Project: p.Project})
+}
+
+// Call the method within a transaction, which is committed only if the method
+// succeeds. Returns nil for notifications.
+func rpc_call(message json.RawMessage) *Rpc_Response {
+	var request Rpc_Request
+	er := json.Unmarshal(message, &request)
+	if er != nil || request.Jsonrpc != "2.0" || request.Method == "" {
+		return &Rpc_Response{
+			Jsonrpc: "2.0",
+			Id:      json.RawMessage("null"),
+			Error:   &Rpc_Error{Code: RPC_INVALID_REQUEST, Message: "Invalid Request"},
+		}
+	}
+	notification := len(request.Id) == 0
+	response := &Rpc_Response{Jsonrpc: "2.0", Id: request.Id}
+
+	method, ok := RPC_METHODS[request.Method]
+	if !ok {
+		response.Error = &Rpc_Error{Code: RPC_METHOD_NOT_FOUND, Message: fmt.Sprintf("Method not found: %s", request.Method)}
+	} else {
+		tx := db.Begin()
+		result, code, message := method(tx, request.Params)
+		if code == common.OK {
+			er = tx.Commit()
+			if er != nil {
+				code = common.COMMIT_ERROR
+				message = er.Error()
+			}
+		}
+		tx.Rollback()
+		if code != common.OK {
+			response.Error = &Rpc_Error{
+				Code:    rpc_error_code(code),
+				Message: message,
+				Data:    &Rpc_Error_Data{Code: code},
+			}
+		} else {
+			response.Result = result
+		}
+	}
+
+	if notification {
+		return nil
+	}
+	return response
+}
+
+// Serve requests until the input ends.
+func serve_rpc(r io.Reader, w io.Writer) int {
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	encoder := json.NewEncoder(w)
+	for {
+		var message json.RawMessage
+		er := decoder.Decode(&message)
+		if er == io.EOF {
+			return common.OK
+		}
+		if er != nil {
+			// Stream cannot be recovered after the malformed JSON.
+			encoder.Encode(&Rpc_Response{
+				Jsonrpc: "2.0",
+				Id:      json.RawMessage("null"),
+				Error:   &Rpc_Error{Code: RPC_PARSE_ERROR, Message: "Parse error"},
+			})
+			return common.INPUT_ERROR
+		}
+
+		trimmed := bytes.TrimSpace(message)
+		if len(trimmed) == 0 || trimmed[0] != '[' {
+			response := rpc_call(message)
+			if response != nil {
+				encoder.Encode(response)
+			}
+			continue
+		}
+
+		batch := []json.RawMessage{}
+		er = json.Unmarshal(message, &batch)
+		if er != nil {
+			encoder.Encode(&Rpc_Response{
+				Jsonrpc: "2.0",
+				Id:      json.RawMessage("null"),
+				Error:   &Rpc_Error{Code: RPC_PARSE_ERROR, Message: "Parse error"},
+			})
+			continue
+		}
+		if len(batch) == 0 {
+			encoder.Encode(&Rpc_Response{
+				Jsonrpc: "2.0",
+				Id:      json.RawMessage("null"),
+				Error:   &Rpc_Error{Code: RPC_INVALID_REQUEST, Message: "Invalid Request"},
+			})
+			continue
+		}
+		responses := []*Rpc_Response{}
+		for _, m := range batch {
+			response := rpc_call(m)
+			if response != nil {
+				responses = append(responses, response)
+			}
+		}
+		if len(responses) > 0 {
+			encoder.Encode(responses)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"tasker/internal/common"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rpc_exchange(t *testing.T, input string) []map[string]any {
+	var output bytes.Buffer
+	serve_rpc(strings.NewReader(input), &output)
+	responses := []map[string]any{}
+	decoder := json.NewDecoder(&output)
+	for decoder.More() {
+		var response map[string]any
+		assert.Nil(t, decoder.Decode(&response))
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+func Test_rpc_ok(t *testing.T) {
+	responses := rpc_exchange(t, `
+		{"jsonrpc": "2.0", "id": 1, "method": "addTask", "params": {"title": "From editor"}}
+		{"jsonrpc": "2.0", "method": "listProjects"}
+		{"jsonrpc": "2.0", "id": 2, "method": "nope"}
+		{"jsonrpc": "2.0", "id": 3, "method": "switchProject", "params": {"project": "missing"}}
+		{"jsonrpc": "2.0", "id": 4, "method": "addTask", "params": {"title": " "}}
+	`)
+	assert.Len(t, responses, 4)
+
+	task := responses[0]["result"].(map[string]any)
+	assert.Equal(t, "From editor", task["title"])
+	assert.Equal(t, float64(RPC_METHOD_NOT_FOUND), responses[1]["error"].(map[string]any)["code"])
+	assert.Equal(t, float64(RPC_SERVER_ERROR-common.NO_SUCH_PROJECT), responses[2]["error"].(map[string]any)["code"])
+	assert.Equal(t, float64(RPC_INVALID_PARAMS), responses[3]["error"].(map[string]any)["code"])
+
+	id := int(task["id"].(float64))
+	responses = rpc_exchange(t, `{"jsonrpc": "2.0", "id": 5, "method": "completeTask", "params": {"id": `+strconv.Itoa(id)+`}}`)
+	assert.Equal(t, "completed", responses[0]["result"].(map[string]any)["state"])
+}
+
+func Test_rpc_error(t *testing.T) {
+	responses := rpc_exchange(t, `[]`)
+	assert.Len(t, responses, 1)
+	assert.Equal(t, float64(RPC_INVALID_REQUEST), responses[0]["error"].(map[string]any)["code"])
+
+	responses = rpc_exchange(t, `[{"jsonrpc": "2.0", "id": 1, "method": "listProjects"}`)
+	assert.Len(t, responses, 1)
+	assert.Equal(t, float64(RPC_PARSE_ERROR), responses[0]["error"].(map[string]any)["code"])
+	assert.Nil(t, responses[0]["id"])
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	SCHEDULE_YEAR = iota
+	SCHEDULE_MONTH
+	SCHEDULE_DAY
+	SCHEDULE_TIME
+)
+
+// Parsed value of the task `schedule` column. The column format is
+// `YYYY[-MM[-DD]] [HH:mm:ss]` in UTC, so a schedule may cover the whole year,
+// month, day, or point to the exact time.
+type Schedule struct {
+	Start     time.Time
+	Precision int
+}
+
+var SCHEDULE_LAYOUTS = []string{
+	"2006",
+	"2006-01",
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+}
+
+func parse_schedule(s string) (*Schedule, bool) {
+	s = strings.TrimSpace(s)
+	for precision, layout := range SCHEDULE_LAYOUTS {
+		if len(s) != len(layout) {
+			continue
+		}
+		start, er := time.ParseInLocation(layout, s, time.UTC)
+		if er != nil {
+			return nil, false
+		}
+		return &Schedule{Start: start, Precision: precision}, true
+	}
+	return nil, false
+}
+
+func (s *Schedule) String() string {
+	return s.Start.Format(SCHEDULE_LAYOUTS[s.Precision])
+}
+
+// Exclusive end of the scheduled period. For exact time schedules equals to
+// the start.
+func (s *Schedule) End() time.Time {
+	switch s.Precision {
+	case SCHEDULE_YEAR:
+		return s.Start.AddDate(1, 0, 0)
+	case SCHEDULE_MONTH:
+		return s.Start.AddDate(0, 1, 0)
+	case SCHEDULE_DAY:
+		return s.Start.AddDate(0, 0, 1)
+	default:
+		return s.Start
+	}
+}
+
+// Whether the schedule covers the whole day, month or year rather than an
+// exact time.
+func (s *Schedule) Is_All_Day() bool {
+	return s.Precision != SCHEDULE_TIME
+}
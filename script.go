@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/db"
+)
+
+// Commands can be run in batches: separated by `;` within one input, from a
+// script file by `tasker --script PATH`, or piped to tasker. Scripts have one
+// batch per line, empty lines and lines starting with `#` are skipped, `q`
+// stops the script.
+//
+// A batch stops on the first failed command. With `--atomic`, all commands of
+// the script run in one transaction, which is rolled back if any of them
+// fails, so either everything is applied or nothing.
+//
+// Scripts cannot answer prompts, so commands asking for confirmation fail.
+
+// Run `;`-separated commands within the passed transaction, if any.
+func process_batch(input string, tx *db.Tx) int {
+	commands := []string{}
+	for _, c := range strings.Split(input, ";") {
+		c = strings.TrimSpace(c)
+		if c != "" {
+			commands = append(commands, c)
+		}
+	}
+	for i, c := range commands {
+		e := process_command(c, tx)
+		if e != common.OK {
+			return e
+		}
+		if prompted && i+1 < len(commands) {
+			bone.Log_Error("Answer the prompt before the rest of the commands: %s", strings.Join(commands[i+1:], "; "))
+			return common.PROMPT_ERROR
+		}
+	}
+	return common.OK
+}
+
+func run_script_file(path string, atomic bool) int {
+	if path == "-" {
+		return run_script(os.Stdin, atomic)
+	}
+	f, er := os.Open(path)
+	if er != nil {
+		bone.Log_Error("Cannot open script '%s': %s", path, er)
+		return common.FILE_ERROR
+	}
+	defer f.Close()
+	return run_script(f, atomic)
+}
+
+// Returns the code of the first failed command.
+func run_script(r io.Reader, atomic bool) int {
+	interactive = false
+
+	var tx *db.Tx
+	if atomic {
+		tx = db.Begin()
+		defer tx.Rollback()
+	}
+
+	scanner := bufio.NewScanner(r)
+	line_number := 0
+	for scanner.Scan() {
+		line_number++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "q" {
+			break
+		}
+		e := process_batch(line, tx)
+		if e != common.OK {
+			bone.Log_Error("Stopped at line %d: %s", line_number, line)
+			if atomic {
+				bone.Log_Error("Nothing is applied, the transaction is rolled back.")
+			}
+			return e
+		}
+	}
+	er := scanner.Err()
+	if er != nil {
+		bone.Log_Error("During script reading, an error occured: %s", er)
+		return common.INPUT_ERROR
+	}
+
+	if atomic {
+		er = tx.Commit()
+		if er != nil {
+			bone.Log_Error("During commit, an error occured: %s", er)
+			return common.COMMIT_ERROR
+		}
+	}
+	return common.OK
+}
+
+// Whether the file is an interactive terminal rather than a pipe or a file.
+func is_terminal(f *os.File) bool {
+	info, er := f.Stat()
+	if er != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
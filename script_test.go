@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func project_exists(t *testing.T, title string) bool {
+	tx := db.Begin()
+	defer tx.Rollback()
+	_, er := get_project(tx, title)
+	return er == nil
+}
+
+func restore_script_state() {
+	interactive = true
+	current_project_id = 1
+	current_project_name = "main"
+}
+
+func Test_run_script_ok(t *testing.T) {
+	defer restore_script_state()
+	script := "# Plan\n\na p script_ok; w script_ok\na t First\nq\na t Skipped\n"
+
+	assert.Equal(t, common.OK, run_script(strings.NewReader(script), true))
+	assert.Equal(t, "script_ok", current_project_name)
+
+	tx := db.Begin()
+	defer tx.Rollback()
+	titles := []string{}
+	assert.Nil(t, tx.Select(&titles, "SELECT title FROM task WHERE project_id = $1", current_project_id))
+	assert.Equal(t, []string{"First"}, titles)
+}
+
+func Test_run_script_error(t *testing.T) {
+	defer restore_script_state()
+
+	assert.Equal(t, common.UNKNOWN_COMMAND, run_script(strings.NewReader("a p script_partial\nnosuch"), false))
+	assert.True(t, project_exists(t, "script_partial"))
+
+	assert.Equal(t, common.UNKNOWN_COMMAND, run_script(strings.NewReader("a p script_atomic; nosuch"), true))
+	assert.False(t, project_exists(t, "script_atomic"))
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/db"
+)
+
+// Local HTTP JSON API, started by `tasker serve --addr HOST:PORT`. Every
+// request should carry `Authorization: Bearer TOKEN` header with the token
+// from `serve.token` of user.cfg.
+//
+// Routes:
+//   - `GET /projects`
+//   - `POST /projects` with `{"title"}`
+//   - `GET /tasks?project=NAME&filter=QUERY`: `filter` takes the `show`
+//     query, e.g. `-c -reverse` or `prio>=1 sort:sched`, project `*` stands
+//     for every project
+//   - `POST /tasks` with `{"title", "project", "priority", "schedule"}`
+//   - `GET /tasks/{id}`
+//   - `PATCH /tasks/{id}` with any of `{"state", "title", "priority",
+//     "schedule", "project"}`, empty schedule unschedules the task, project
+//     moves it
+//   - `DELETE /tasks/{id}`
+//
+// Tasks are represented the same way as by the JSON export. Errors are
+// returned as `{"code", "error"}`, where code is one of `common` codes.
+
+const DEFAULT_SERVE_ADDR = "127.0.0.1:8420"
+
+type Api_Error struct {
+	Code  int    `json:"code"`
+	Error string `json:"error"`
+}
+
+type Api_Project_Input struct {
+	Title string `json:"title"`
+}
+
+type Api_Task_Input struct {
+	State    *string `json:"state"`
+	Title    *string `json:"title"`
+	Priority *int    `json:"priority"`
+	Schedule *string `json:"schedule"`
+	Project  *string `json:"project"`
+}
+
+type api_handler func(tx *db.Tx, r *http.Request) (any, int, string)
+
+// SQLite doesn't like concurrent writers, so requests are served one by one.
+var api_mutex sync.Mutex
+
+func api_status(code int) int {
+	switch code {
+	case common.INPUT_ERROR, common.CONVERSION_ERROR:
+		return http.StatusBadRequest
+	case common.NO_SUCH_TASK, common.NO_SUCH_PROJECT:
+		return http.StatusNotFound
+	case common.ALREADY_EXISTS:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func write_json(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// Run the handler within a transaction, which is committed only if the
+// handler succeeds.
+func api_route(handle api_handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		api_mutex.Lock()
+		defer api_mutex.Unlock()
+
+		tx := db.Begin()
+		defer tx.Rollback()
+
+		result, code, message := handle(tx, r)
+		if code != common.OK {
+			write_json(w, api_status(code), &Api_Error{Code: code, Error: message})
+			return
+		}
+		er := tx.Commit()
+		if er != nil {
+			write_json(w, http.StatusInternalServerError, &Api_Error{Code: common.COMMIT_ERROR, Error: er.Error()})
+			return
+		}
+
+		if result == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		status := http.StatusOK
+		if r.Method == http.MethodPost {
+			status = http.StatusCreated
+		}
+		write_json(w, status, result)
+	}
+}
+
+func new_api_handler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /projects", api_route(api_list_projects))
+	mux.HandleFunc("POST /projects", api_route(api_create_project))
+	mux.HandleFunc("GET /tasks", api_route(api_list_tasks))
+	mux.HandleFunc("POST /tasks", api_route(api_create_task))
+	mux.HandleFunc("GET /tasks/{id}", api_route(api_get_task))
+	mux.HandleFunc("PATCH /tasks/{id}", api_route(api_update_task))
+	mux.HandleFunc("DELETE /tasks/{id}", api_route(api_delete_task))
+
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actual := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(actual, expected) != 1 {
+			write_json(w, http.StatusUnauthorized, &Api_Error{Code: common.WRONG_REQUEST, Error: "invalid token"})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func api_list_projects(tx *db.Tx, r *http.Request) (any, int, string) {
+	projects := []*Project{}
+	er := tx.Select(&projects, "SELECT * FROM project ORDER BY id ASC")
+	if er != nil {
+		return nil, common.SELECT_ERROR, er.Error()
+	}
+	return projects, common.OK, ""
+}
+
+func api_create_project(tx *db.Tx, r *http.Request) (any, int, string) {
+	var input Api_Project_Input
+	er := json.NewDecoder(r.Body).Decode(&input)
+	if er != nil {
+		return nil, common.INPUT_ERROR, er.Error()
+	}
+	return api_create_project_input(tx, &input)
+}
+
+func api_create_project_input(tx *db.Tx, input *Api_Project_Input) (any, int, string) {
+	input.Title = strings.TrimSpace(input.Title)
+	if input.Title == "" {
+		return nil, common.INPUT_ERROR, "empty title"
+	}
+	_, er := get_project(tx, input.Title)
+	if er == nil {
+		return nil, common.ALREADY_EXISTS, fmt.Sprintf("project '%s' already exists", input.Title)
+	}
+	id, er := insert_project(tx, input.Title)
+	if er != nil {
+		return nil, common.INSERT_ERROR, er.Error()
+	}
+	return &Project{Id: id, Title: input.Title}, common.OK, ""
+}
+
+func api_list_tasks(tx *db.Tx, r *http.Request) (any, int, string) {
+	query := r.URL.Query()
+	return api_query_tasks(tx, query.Get("project"), query.Get("filter"))
+}
+
+// Select tasks of the project with the `show` flags. Empty project name
+// stands for the current project, `*` for every project.
+func api_query_tasks(tx *db.Tx, project_name string, filter string) (any, int, string) {
+	project_ids := []int{current_project_id}
+	if project_name == "*" {
+		project_ids = nil
+	} else if project_name != "" {
+		project, er := get_project(tx, project_name)
+		if er != nil {
+			return nil, common.NO_SUCH_PROJECT, fmt.Sprintf("no project '%s'", project_name)
+		}
+		project_ids = []int{project.Id}
+	}
+
+	task_filter, er := build_task_filter(strings.Fields(filter), project_ids)
+	if er != nil {
+		return nil, common.INPUT_ERROR, er.Error()
+	}
+	tasks := []*Task{}
+	er = tx.Select(&tasks, fmt.Sprintf("SELECT * FROM task %s %s", task_filter.Where, task_filter.Order), task_filter.Args...)
+	if er != nil {
+		return nil, common.SELECT_ERROR, er.Error()
+	}
+	export_tasks, er := to_export_tasks(tx, task_filter.Page(tasks))
+	if er != nil {
+		return nil, common.SELECT_ERROR, er.Error()
+	}
+	return export_tasks, common.OK, ""
+}
+
+func api_task_id(r *http.Request) (int, bool) {
+	id, er := strconv.Atoi(r.PathValue("id"))
+	return id, er == nil
+}
+
+func api_export_task(tx *db.Tx, task_id int) (any, int, string) {
+	task, er := get_task(tx, task_id)
+	if er != nil {
+		return nil, common.NO_SUCH_TASK, fmt.Sprintf("no task %d", task_id)
+	}
+	export_tasks, er := to_export_tasks(tx, []*Task{task})
+	if er != nil {
+		return nil, common.SELECT_ERROR, er.Error()
+	}
+	return export_tasks[0], common.OK, ""
+}
+
+func api_get_task(tx *db.Tx, r *http.Request) (any, int, string) {
+	id, ok := api_task_id(r)
+	if !ok {
+		return nil, common.INPUT_ERROR, "task id should be integer"
+	}
+	return api_export_task(tx, id)
+}
+
+// Apply the input fields to the task, skipping the absent ones.
+func api_apply_task_input(tx *db.Tx, task_id int, input *Api_Task_Input) (int, string) {
+	var er error
+	if input.Title != nil {
+		title := strings.TrimSpace(*input.Title)
+		if title == "" {
+			return common.INPUT_ERROR, "empty title"
+		}
+		er = set_task_title(tx, task_id, title)
+		if er != nil {
+			return common.UPDATE_ERROR, er.Error()
+		}
+	}
+	if input.State != nil {
+		state, ok := parse_state_name(*input.State)
+		if !ok {
+			return common.INPUT_ERROR, fmt.Sprintf("unknown state '%s'", *input.State)
+		}
+		er = set_task_state(tx, task_id, state)
+		if er != nil {
+			return common.UPDATE_ERROR, er.Error()
+		}
+	}
+	if input.Priority != nil {
+		if *input.Priority < SOMETIME_LATER_PRIORITY || *input.Priority > TODAY_PRIORITY {
+			return common.INPUT_ERROR, fmt.Sprintf("unknown priority %d", *input.Priority)
+		}
+		er = set_task_priority(tx, task_id, *input.Priority)
+		if er != nil {
+			return common.UPDATE_ERROR, er.Error()
+		}
+	}
+	if input.Schedule != nil {
+		var schedule_str *string
+		if *input.Schedule != "" {
+			schedule, ok := parse_schedule(*input.Schedule)
+			if !ok {
+				return common.INPUT_ERROR, fmt.Sprintf("invalid schedule '%s'", *input.Schedule)
+			}
+			schedule_str = bone.Atop(schedule.String())
+		}
+		er = set_task_schedule(tx, task_id, schedule_str)
+		if er != nil {
+			return common.UPDATE_ERROR, er.Error()
+		}
+	}
+	if input.Project != nil {
+		project, er := get_project(tx, *input.Project)
+		if er != nil {
+			return common.NO_SUCH_PROJECT, fmt.Sprintf("no project '%s'", *input.Project)
+		}
+		er = move_task(tx, task_id, project.Id)
+		if er != nil {
+			return common.UPDATE_ERROR, er.Error()
+		}
+	}
+	return common.OK, ""
+}
+
+func api_create_task(tx *db.Tx, r *http.Request) (any, int, string) {
+	var input Api_Task_Input
+	er := json.NewDecoder(r.Body).Decode(&input)
+	if er != nil {
+		return nil, common.INPUT_ERROR, er.Error()
+	}
+	return api_create_task_input(tx, &input)
+}
+
+func api_create_task_input(tx *db.Tx, input *Api_Task_Input) (any, int, string) {
+	if input.Title == nil || strings.TrimSpace(*input.Title) == "" {
+		return nil, common.INPUT_ERROR, "empty title"
+	}
+
+	id, er := insert_task(tx, strings.TrimSpace(*input.Title), current_project_id)
+	if er != nil {
+		return nil, common.INSERT_ERROR, er.Error()
+	}
+	code, message := api_apply_task_input(tx, id, input)
+	if code != common.OK {
+		return nil, code, message
+	}
+	return api_export_task(tx, id)
+}
+
+func api_update_task(tx *db.Tx, r *http.Request) (any, int, string) {
+	id, ok := api_task_id(r)
+	if !ok {
+		return nil, common.INPUT_ERROR, "task id should be integer"
+	}
+	var input Api_Task_Input
+	er := json.NewDecoder(r.Body).Decode(&input)
+	if er != nil {
+		return nil, common.INPUT_ERROR, er.Error()
+	}
+	return api_update_task_input(tx, id, &input)
+}
+
+func api_update_task_input(tx *db.Tx, id int, input *Api_Task_Input) (any, int, string) {
+	_, er := get_task(tx, id)
+	if er != nil {
+		return nil, common.NO_SUCH_TASK, fmt.Sprintf("no task %d", id)
+	}
+	code, message := api_apply_task_input(tx, id, input)
+	if code != common.OK {
+		return nil, code, message
+	}
+	return api_export_task(tx, id)
+}
+
+func api_delete_task(tx *db.Tx, r *http.Request) (any, int, string) {
+	id, ok := api_task_id(r)
+	if !ok {
+		return nil, common.INPUT_ERROR, "task id should be integer"
+	}
+	return api_delete_task_id(tx, id)
+}
+
+func api_delete_task_id(tx *db.Tx, id int) (any, int, string) {
+	_, er := get_task(tx, id)
+	if er != nil {
+		return nil, common.NO_SUCH_TASK, fmt.Sprintf("no task %d", id)
+	}
+	er = delete_task(tx, id)
+	if er != nil {
+		return nil, common.DELETE_ERROR, er.Error()
+	}
+	return nil, common.OK, ""
+}
+
+// Start the API server, blocks until the server fails.
+//
+// Args:
+//   - `--addr HOST:PORT` (default="127.0.0.1:8420"): address to listen on
+func serve(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", DEFAULT_SERVE_ADDR, "Address to listen on.")
+	er := flags.Parse(args)
+	if er != nil {
+		return common.INPUT_ERROR
+	}
+
+	token := bone.Config.Get_String("serve", "token", "")
+	if token == "" {
+		bone.Log_Error("Set `token` in the `serve` section of user.cfg to start the server.")
+		return common.INPUT_ERROR
+	}
+
+	bone.Log("Serving API at http://%s", *addr)
+	er = http.ListenAndServe(*addr, new_api_handler(token))
+	bone.Log_Error("Server stopped: %s", er)
+	return common.ERROR
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func api_request(t *testing.T, server *httptest.Server, token string, method string, path string, body string) (int, []byte) {
+	request, er := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	assert.Nil(t, er)
+	request.Header.Set("Authorization", "Bearer "+token)
+	response, er := server.Client().Do(request)
+	assert.Nil(t, er)
+	defer response.Body.Close()
+	data, er := io.ReadAll(response.Body)
+	assert.Nil(t, er)
+	return response.StatusCode, data
+}
+
+func Test_api_unauthorized(t *testing.T) {
+	server := httptest.NewServer(new_api_handler("secret"))
+	defer server.Close()
+
+	status, _ := api_request(t, server, "wrong", "GET", "/projects", "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func Test_api_tasks_ok(t *testing.T) {
+	server := httptest.NewServer(new_api_handler("secret"))
+	defer server.Close()
+
+	status, _ := api_request(t, server, "secret", "POST", "/projects", `{"title": "api"}`)
+	assert.Equal(t, http.StatusCreated, status)
+	status, _ = api_request(t, server, "secret", "POST", "/projects", `{"title": "api"}`)
+	assert.Equal(t, http.StatusConflict, status)
+
+	status, data := api_request(t, server, "secret", "POST", "/tasks", `{"title": "Write docs", "project": "api", "priority": 2, "schedule": "2026-05"}`)
+	assert.Equal(t, http.StatusCreated, status)
+	var task Export_Task
+	assert.Nil(t, json.Unmarshal(data, &task))
+	assert.Equal(t, "Write docs", task.Title)
+	assert.Equal(t, "api", task.Project)
+	assert.Equal(t, TODAY_PRIORITY, task.Priority)
+	assert.Equal(t, "2026-05", *task.Schedule)
+
+	status, _ = api_request(t, server, "secret", "POST", "/tasks", `{"title": "Bad", "schedule": "soon"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	path := fmt.Sprintf("/tasks/%d", task.Id)
+	status, data = api_request(t, server, "secret", "PATCH", path, `{"state": "completed", "schedule": ""}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Nil(t, json.Unmarshal(data, &task))
+	assert.Equal(t, "completed", task.State)
+	assert.Nil(t, task.Schedule)
+
+	tasks := []*Export_Task{}
+	status, data = api_request(t, server, "secret", "GET", "/tasks?project=api&filter=-c", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Nil(t, json.Unmarshal(data, &tasks))
+	assert.Len(t, tasks, 1)
+
+	status, _ = api_request(t, server, "secret", "PATCH", path, `{"project": "main"}`)
+	assert.Equal(t, http.StatusOK, status)
+	status, data = api_request(t, server, "secret", "GET", "/tasks?project=api&filter=-c", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Nil(t, json.Unmarshal(data, &tasks))
+	assert.Len(t, tasks, 0)
+
+	status, _ = api_request(t, server, "secret", "DELETE", path, "")
+	assert.Equal(t, http.StatusNoContent, status)
+	status, _ = api_request(t, server, "secret", "GET", path, "")
+	assert.Equal(t, http.StatusNotFound, status)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"sort"
+	"tasker/internal/db"
+)
+
+type Task_Tag struct {
+	Task_Id int    `db:"task_id"`
+	Name    string `db:"name"`
+}
+
+// Get tags of all tasks as `{task_id: [name, ...]}`, names are sorted.
+func get_task_tags(tx *db.Tx) (map[int][]string, error) {
+	rows := []*Task_Tag{}
+	er := tx.Select(&rows, "SELECT * FROM task_tag ORDER BY task_id ASC, name ASC")
+	if er != nil {
+		return nil, er
+	}
+	tags := map[int][]string{}
+	for _, r := range rows {
+		tags[r.Task_Id] = append(tags[r.Task_Id], r.Name)
+	}
+	return tags, nil
+}
+
+// Replace tags of the task.
+func set_task_tags(tx *db.Tx, task_id int, tags []string) error {
+	_, er := tx.Exec("DELETE FROM task_tag WHERE task_id = $1", task_id)
+	if er != nil {
+		return er
+	}
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+	for i, name := range sorted {
+		if i > 0 && sorted[i-1] == name {
+			continue
+		}
+		_, er = tx.Exec("INSERT INTO task_tag (task_id, name) VALUES ($1, $2)", task_id, name)
+		if er != nil {
+			return er
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"tasker/internal/bone"
+	"tasker/internal/db"
+)
+
+// Task operations shared by the command handlers and the API. All of them work
+// within the passed transaction, committing is up to the caller.
+
+func get_task(tx *db.Tx, task_id int) (*Task, error) {
+	var task Task
+	er := tx.Get(&task, "SELECT * FROM task WHERE id = $1", task_id)
+	if er != nil {
+		return nil, er
+	}
+	return &task, nil
+}
+
+func get_project(tx *db.Tx, title string) (*Project, error) {
+	var project Project
+	er := tx.Get(&project, "SELECT * FROM project WHERE title = $1", title)
+	if er != nil {
+		return nil, er
+	}
+	return &project, nil
+}
+
+func get_project_titles(tx *db.Tx) (map[int]string, error) {
+	projects := []*Project{}
+	er := tx.Select(&projects, "SELECT * FROM project")
+	if er != nil {
+		return nil, er
+	}
+	titles := map[int]string{}
+	for _, p := range projects {
+		titles[p.Id] = p.Title
+	}
+	return titles, nil
+}
+
+func insert_task(tx *db.Tx, title string, project_id int) (int, error) {
+	result, er := tx.Exec(
+		"INSERT INTO task (title, created_sec, project_id) VALUES ($1, $2, $3)",
+		title,
+		bone.Utc(),
+		project_id,
+	)
+	if er != nil {
+		return 0, er
+	}
+	id, er := result.LastInsertId()
+	return int(id), er
+}
+
+func insert_project(tx *db.Tx, title string) (int, error) {
+	result, er := tx.Exec("INSERT INTO project (title) VALUES ($1)", title)
+	if er != nil {
+		return 0, er
+	}
+	id, er := result.LastInsertId()
+	return int(id), er
+}
+
+// Completion and rejection also stamp the according time.
+func set_task_state(tx *db.Tx, task_id int, state int) error {
+	var er error
+	switch state {
+	case COMPLETED:
+		_, er = tx.Exec("UPDATE task SET last_completed_sec = $2, state = $3 WHERE id = $1", task_id, bone.Utc(), COMPLETED)
+	case REJECTED:
+		_, er = tx.Exec("UPDATE task SET last_rejected_sec = $2, state = $3 WHERE id = $1", task_id, bone.Utc(), REJECTED)
+	default:
+		_, er = tx.Exec("UPDATE task SET state = $2 WHERE id = $1", task_id, ACTIVE)
+	}
+	return er
+}
+
+func move_task(tx *db.Tx, task_id int, project_id int) error {
+	_, er := tx.Exec("UPDATE task SET project_id = $1 WHERE id = $2", project_id, task_id)
+	return er
+}
+
+func delete_task(tx *db.Tx, task_id int) error {
+	_, er := tx.Exec("DELETE FROM task WHERE id = $1", task_id)
+	return er
+}
+
+func set_task_title(tx *db.Tx, task_id int, title string) error {
+	_, er := tx.Exec("UPDATE task SET title = $1 WHERE id = $2", title, task_id)
+	return er
+}
+
+func set_task_priority(tx *db.Tx, task_id int, priority int) error {
+	_, er := tx.Exec("UPDATE task SET completion_priority = $1 WHERE id = $2", priority, task_id)
+	return er
+}
+
+// Nil schedule unschedules the task.
+func set_task_schedule(tx *db.Tx, task_id int, schedule *string) error {
+	_, er := tx.Exec("UPDATE task SET schedule = $1 WHERE id = $2", schedule, task_id)
+	return er
+}
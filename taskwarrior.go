@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"time"
+)
+
+// Conversion between tasks and the Taskwarrior `task export` JSON, see
+// https://taskwarrior.org/docs/design/task
+//
+// Mapping:
+//   - `status`: `pending`, `waiting` and `recurring` are active, `deleted` is
+//     rejected
+//   - `entry`: creation time
+//   - `end`: completion or rejection time, depending on the status
+//   - `due`, or `scheduled` if there is no due: schedule
+//   - `priority`: `H` is today, `M` is this week, `L` and none are sometime
+//     later
+//   - `project`: task project
+//   - `tags`: task tags
+//
+// Everything else, including `uuid`, is kept in the extra column and written
+// back on export. Working set `id` and `urgency` are computed by Taskwarrior
+// and dropped.
+
+const TASKWARRIOR_DATE_LAYOUT = "20060102T150405Z"
+
+var TASKWARRIOR_DROPPED_FIELDS = []string{"id", "urgency"}
+
+func parse_taskwarrior_date(value any) (time.Time, error) {
+	s, ok := value.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("date should be a string, got '%v'", value)
+	}
+	date, er := time.ParseInLocation(TASKWARRIOR_DATE_LAYOUT, s, time.UTC)
+	if er != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%s'", s)
+	}
+	return date, nil
+}
+
+func taskwarrior_schedule(date time.Time) string {
+	if date.Hour() == 0 && date.Minute() == 0 && date.Second() == 0 {
+		return date.Format(SCHEDULE_LAYOUTS[SCHEDULE_DAY])
+	}
+	return date.Format(SCHEDULE_LAYOUTS[SCHEDULE_TIME])
+}
+
+// Parse either the JSON array of the modern `task export`, or the object per
+// line format of the older versions. The first malformed task fails the whole
+// body.
+func parse_taskwarrior(body string) ([]*Import_Task, error) {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+
+	objects := []map[string]any{}
+	if strings.HasPrefix(strings.TrimSpace(body), "[") {
+		er := decoder.Decode(&objects)
+		if er != nil {
+			return nil, er
+		}
+	} else {
+		for {
+			var object map[string]any
+			er := decoder.Decode(&object)
+			if er == io.EOF {
+				break
+			}
+			if er != nil {
+				return nil, er
+			}
+			objects = append(objects, object)
+		}
+	}
+
+	tasks := []*Import_Task{}
+	for i, object := range objects {
+		t, er := parse_taskwarrior_task(object)
+		if er != nil {
+			return nil, fmt.Errorf("task %d: %s", i+1, er)
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
+
+func parse_taskwarrior_task(object map[string]any) (*Import_Task, error) {
+	t := &Import_Task{Tags: []string{}}
+	extra := map[string]any{}
+	for k, v := range object {
+		extra[k] = v
+	}
+	for _, k := range TASKWARRIOR_DROPPED_FIELDS {
+		delete(extra, k)
+	}
+
+	description, ok := object["description"].(string)
+	if !ok || strings.TrimSpace(description) == "" {
+		return nil, fmt.Errorf("missing description")
+	}
+	t.Title = strings.TrimSpace(description)
+	delete(extra, "description")
+
+	status, _ := object["status"].(string)
+	switch status {
+	case "pending", "":
+		t.State = ACTIVE
+		delete(extra, "status")
+	case "waiting", "recurring":
+		// Keep the status to export it back as is.
+		t.State = ACTIVE
+	case "completed":
+		t.State = COMPLETED
+		delete(extra, "status")
+	case "deleted":
+		t.State = REJECTED
+		delete(extra, "status")
+	default:
+		return nil, fmt.Errorf("unknown status '%s'", status)
+	}
+
+	if value, ok := object["entry"]; ok {
+		entry, er := parse_taskwarrior_date(value)
+		if er != nil {
+			return nil, er
+		}
+		t.Created_Sec = int(entry.Unix())
+		delete(extra, "entry")
+	}
+
+	if value, ok := object["end"]; ok {
+		end, er := parse_taskwarrior_date(value)
+		if er != nil {
+			return nil, er
+		}
+		switch t.State {
+		case COMPLETED:
+			t.Last_Completed_Sec = int(end.Unix())
+			delete(extra, "end")
+		case REJECTED:
+			t.Last_Rejected_Sec = int(end.Unix())
+			delete(extra, "end")
+		}
+	}
+
+	for _, k := range []string{"due", "scheduled"} {
+		value, ok := object[k]
+		if !ok {
+			continue
+		}
+		date, er := parse_taskwarrior_date(value)
+		if er != nil {
+			return nil, er
+		}
+		if t.Schedule == nil {
+			t.Schedule = bone.Atop(taskwarrior_schedule(date))
+			delete(extra, k)
+		}
+	}
+
+	priority, _ := object["priority"].(string)
+	switch priority {
+	case "H":
+		t.Priority = TODAY_PRIORITY
+		delete(extra, "priority")
+	case "M":
+		t.Priority = THIS_WEEK_PRIORITY
+		delete(extra, "priority")
+	case "":
+		t.Priority = SOMETIME_LATER_PRIORITY
+	default:
+		// `L` is kept in the extra column, since we don't distinguish it from
+		// the unset priority.
+		t.Priority = SOMETIME_LATER_PRIORITY
+	}
+
+	if value, ok := object["project"]; ok {
+		project, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("project should be a string, got '%v'", value)
+		}
+		t.Project = project
+		delete(extra, "project")
+	}
+
+	if value, ok := object["tags"]; ok {
+		tags, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("tags should be a list, got '%v'", value)
+		}
+		for _, tag := range tags {
+			name, ok := tag.(string)
+			if !ok {
+				return nil, fmt.Errorf("tag should be a string, got '%v'", tag)
+			}
+			t.Tags = append(t.Tags, name)
+		}
+		delete(extra, "tags")
+	}
+
+	if len(extra) > 0 {
+		data, er := json.Marshal(extra)
+		if er != nil {
+			return nil, er
+		}
+		t.Extra = bone.Atop(string(data))
+	}
+	return t, nil
+}
+
+func format_taskwarrior_task(t *Export_Task) (map[string]any, error) {
+	object := map[string]any{}
+	if len(t.Extra) > 0 {
+		decoder := json.NewDecoder(strings.NewReader(string(t.Extra)))
+		decoder.UseNumber()
+		er := decoder.Decode(&object)
+		if er != nil {
+			return nil, fmt.Errorf("task %d has malformed extra: %s", t.Id, er)
+		}
+	}
+
+	if _, ok := object["uuid"]; !ok {
+		object["uuid"] = task_uuid(t.Id)
+	}
+	object["description"] = t.Title
+	object["entry"] = time.Unix(int64(t.Created_Sec), 0).UTC().Format(TASKWARRIOR_DATE_LAYOUT)
+
+	switch t.State {
+	case "completed":
+		object["status"] = "completed"
+		object["end"] = time.Unix(int64(t.Last_Completed_Sec), 0).UTC().Format(TASKWARRIOR_DATE_LAYOUT)
+	case "rejected":
+		object["status"] = "deleted"
+		object["end"] = time.Unix(int64(t.Last_Rejected_Sec), 0).UTC().Format(TASKWARRIOR_DATE_LAYOUT)
+	default:
+		status, _ := object["status"].(string)
+		if status != "waiting" && status != "recurring" {
+			object["status"] = "pending"
+		}
+	}
+
+	if t.Schedule != nil {
+		schedule, ok := parse_schedule(*t.Schedule)
+		if !ok {
+			return nil, fmt.Errorf("task %d has malformed schedule '%s'", t.Id, *t.Schedule)
+		}
+		object["due"] = schedule.Start.Format(TASKWARRIOR_DATE_LAYOUT)
+	}
+
+	switch t.Priority {
+	case TODAY_PRIORITY:
+		object["priority"] = "H"
+	case THIS_WEEK_PRIORITY:
+		object["priority"] = "M"
+	}
+
+	if t.Project != "" {
+		object["project"] = t.Project
+	}
+	if len(t.Tags) > 0 {
+		tags := append([]string{}, t.Tags...)
+		sort.Strings(tags)
+		object["tags"] = tags
+	}
+	return object, nil
+}
+
+func export_taskwarrior(w io.Writer, tasks []*Export_Task) int {
+	objects := []map[string]any{}
+	for _, t := range tasks {
+		object, er := format_taskwarrior_task(t)
+		if er != nil {
+			bone.Log_Error("During Taskwarrior conversion, an error occured: %s", er)
+			return common.CONVERSION_ERROR
+		}
+		objects = append(objects, object)
+	}
+	encoder := json.NewEncoder(w)
+	er := encoder.Encode(objects)
+	if er != nil {
+		bone.Log_Error("During JSON encoding, an error occured: %s", er)
+		return common.FILE_ERROR
+	}
+	return common.OK
+}
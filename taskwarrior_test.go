@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_taskwarrior_parse_ok(t *testing.T) {
+	tasks, er := parse_taskwarrior(`[
+		{"id": 1, "description": " Call mom ", "status": "pending", "entry": "20260430T080000Z", "due": "20260530T000000Z", "priority": "H", "project": "family", "tags": ["phone", "home"], "urgency": 8.1},
+		{"description": "Pay bills", "status": "completed", "end": "20260502T100000Z", "scheduled": "20260501T093000Z", "priority": "M"},
+		{"description": "Water plants", "status": "deleted", "end": "20260503T000000Z", "due": "20260504T000000Z", "scheduled": "20260503T000000Z"},
+		{"description": "Read", "status": "waiting", "priority": "L"}
+	]`)
+	assert.Nil(t, er)
+	assert.Len(t, tasks, 4)
+
+	call := tasks[0]
+	assert.Equal(t, "Call mom", call.Title)
+	assert.Equal(t, ACTIVE, call.State)
+	assert.Equal(t, int(time.Date(2026, 4, 30, 8, 0, 0, 0, time.UTC).Unix()), call.Created_Sec)
+	assert.Equal(t, "2026-05-30", *call.Schedule)
+	assert.Equal(t, TODAY_PRIORITY, call.Priority)
+	assert.Equal(t, "family", call.Project)
+	assert.Equal(t, []string{"phone", "home"}, call.Tags)
+	assert.Nil(t, call.Extra)
+
+	pay := tasks[1]
+	assert.Equal(t, COMPLETED, pay.State)
+	assert.Equal(t, int(time.Date(2026, 5, 2, 10, 0, 0, 0, time.UTC).Unix()), pay.Last_Completed_Sec)
+	assert.Equal(t, "2026-05-01 09:30:00", *pay.Schedule)
+	assert.Equal(t, THIS_WEEK_PRIORITY, pay.Priority)
+	assert.Equal(t, []string{}, pay.Tags)
+
+	// Due wins over scheduled, which is then kept as extra.
+	water := tasks[2]
+	assert.Equal(t, REJECTED, water.State)
+	assert.Equal(t, int(time.Date(2026, 5, 3, 0, 0, 0, 0, time.UTC).Unix()), water.Last_Rejected_Sec)
+	assert.Equal(t, "2026-05-04", *water.Schedule)
+	assert.JSONEq(t, `{"scheduled": "20260503T000000Z"}`, *water.Extra)
+
+	read := tasks[3]
+	assert.Equal(t, ACTIVE, read.State)
+	assert.Equal(t, SOMETIME_LATER_PRIORITY, read.Priority)
+	assert.JSONEq(t, `{"status": "waiting", "priority": "L"}`, *read.Extra)
+
+	// Older versions export an object per line.
+	tasks, er = parse_taskwarrior("{\"description\": \"One\"}\n{\"description\": \"Two\"}\n")
+	assert.Nil(t, er)
+	assert.Equal(t, "Two", tasks[1].Title)
+}
+
+func Test_taskwarrior_parse_error(t *testing.T) {
+	_, er := parse_taskwarrior(`[{"description": "Ok"}, {"description": " "}]`)
+	assert.EqualError(t, er, "task 2: missing description")
+
+	_, er = parse_taskwarrior(`[{"description": "Sleep", "status": "sleeping"}]`)
+	assert.EqualError(t, er, "task 1: unknown status 'sleeping'")
+
+	_, er = parse_taskwarrior(`[{"description": "Call", "due": "tomorrow"}]`)
+	assert.EqualError(t, er, "task 1: invalid date 'tomorrow'")
+
+	_, er = parse_taskwarrior(`[{"description": "Call", "tags": "phone"}]`)
+	assert.EqualError(t, er, "task 1: tags should be a list, got 'phone'")
+}
+
+func Test_taskwarrior_round_trip_ok(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tasks.json")
+	body := `[
+		{"id": 3, "uuid": "4f1c1a8e-6f1b-4d67-9a43-2c3a5d1e2b10", "description": "Fix roof", "status": "waiting", "entry": "20260430T080000Z", "wait": "20260601T000000Z", "due": "20260610T000000Z", "priority": "L", "project": "taskwarrior", "tags": ["house"], "annotations": [{"entry": "20260430T081500Z", "description": "Ask for a quote"}], "estimate": 3, "urgency": 2.4},
+		{"description": "Order tiles", "status": "completed", "entry": "20260430T090000Z", "end": "20260501T120000Z", "priority": "H", "project": "taskwarrior"}
+	]`
+	assert.Nil(t, os.WriteFile(path, []byte(body), 0644))
+	process_input("import taskwarrior " + path)
+
+	exported := filepath.Join(dir, "exported.json")
+	process_input("export taskwarrior -a -project taskwarrior -o " + exported)
+	data, er := os.ReadFile(exported)
+	assert.Nil(t, er)
+	objects := []map[string]any{}
+	assert.Nil(t, json.Unmarshal(data, &objects))
+	by_description := map[string]map[string]any{}
+	for _, object := range objects {
+		if object["project"] == "taskwarrior" {
+			by_description[object["description"].(string)] = object
+		}
+	}
+	assert.Len(t, by_description, 2)
+
+	roof := by_description["Fix roof"]
+	assert.Equal(t, "4f1c1a8e-6f1b-4d67-9a43-2c3a5d1e2b10", roof["uuid"])
+	assert.Equal(t, "Fix roof", roof["description"])
+	assert.Equal(t, "waiting", roof["status"])
+	assert.Equal(t, "20260430T080000Z", roof["entry"])
+	assert.Equal(t, "20260601T000000Z", roof["wait"])
+	assert.Equal(t, "20260610T000000Z", roof["due"])
+	assert.Equal(t, "L", roof["priority"])
+	assert.Equal(t, "taskwarrior", roof["project"])
+	assert.Equal(t, []any{"house"}, roof["tags"])
+	assert.Equal(t, []any{map[string]any{"entry": "20260430T081500Z", "description": "Ask for a quote"}}, roof["annotations"])
+	assert.Equal(t, float64(3), roof["estimate"])
+	assert.NotContains(t, roof, "id")
+	assert.NotContains(t, roof, "urgency")
+
+	tiles := by_description["Order tiles"]
+	assert.NotEqual(t, roof["uuid"], tiles["uuid"])
+	assert.Equal(t, "completed", tiles["status"])
+	assert.Equal(t, "20260501T120000Z", tiles["end"])
+	assert.Equal(t, "H", tiles["priority"])
+	assert.NotContains(t, tiles, "tags")
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/term"
+)
+
+// Marks and colors of the terminal output are set in the `theme` section of
+// user.cfg:
+//
+//	[theme]
+//	; `auto` (default), `always` or `never`
+//	color = auto
+//	; `auto` (default), `true` or `false`
+//	ascii = auto
+//	mark_active = .
+//	color_active = magenta
+//	mark_completed = +
+//	color_completed = green
+//	mark_rejected = -
+//	color_rejected = red
+//	prio_later = 🟢
+//	prio_week = 🟡
+//	prio_today = 🔴
+//	color_prompt_project = yellow
+//	color_prompt_sign = magenta
+//	color_error = bright_red
+//	; backgrounds of the heatmap levels, from no completions to the most
+//	color_heat_0 = 48;5;236
+//	color_heat_1 = 48;5;22
+//	color_heat_2 = 48;5;28
+//	color_heat_3 = 48;5;34
+//	color_heat_4 = 48;5;40
+//
+// Colors are either names, or SGR codes like `1;31`, `none` disables the
+// color. Marks, which ini reads as comments, like `#`, should be quoted by
+// backticks.
+//
+// Automatic colors are disabled by the `NO_COLOR` environ, or if the output
+// is not a terminal. Automatic ASCII mode, which replaces the emoji, the box
+// drawing, the chart blocks and the heatmap shades, is enabled for the Linux
+// console, dumb terminals and non-UTF-8 locales.
+
+type Theme struct {
+	// By the task states.
+	State_Marks  [3]string
+	State_Colors [3]string
+	// By the priorities.
+	Priority_Marks       [3]string
+	Prompt_Project_Color string
+	Prompt_Sign_Color    string
+	Error_Color          string
+	// Vertical line between the TUI panes, and the axis of the charts.
+	Separator string
+	// Chart bars and the horizontal axis.
+	Bar         string
+	Axis_Line   string
+	Axis_Corner string
+	// By the heatmap levels, shades are used without the colors.
+	Heat_Shades [5]string
+	Heat_Colors [5]string
+}
+
+var COLORS = map[string]string{
+	"none":           "",
+	"bold":           "1",
+	"black":          "30",
+	"red":            "31",
+	"green":          "32",
+	"yellow":         "33",
+	"blue":           "34",
+	"magenta":        "35",
+	"cyan":           "36",
+	"white":          "37",
+	"bright_black":   "90",
+	"bright_red":     "91",
+	"bright_green":   "92",
+	"bright_yellow":  "93",
+	"bright_blue":    "94",
+	"bright_magenta": "95",
+	"bright_cyan":    "96",
+	"bright_white":   "97",
+}
+
+var sgr_code_regex = regexp.MustCompile(`^[0-9]+(;[0-9]+)*$`)
+
+func default_theme(ascii bool) *Theme {
+	theme := &Theme{
+		State_Marks:          [3]string{".", "+", "-"},
+		State_Colors:         [3]string{"35", "32", "31"},
+		Priority_Marks:       [3]string{"🟢", "🟡", "🔴"},
+		Prompt_Project_Color: "33",
+		Prompt_Sign_Color:    "35",
+		Error_Color:          "91",
+		Separator:            "│",
+		Bar:                  "█",
+		Axis_Line:            "─",
+		Axis_Corner:          "└",
+		Heat_Shades:          [5]string{"·", "░", "▒", "▓", "█"},
+		Heat_Colors:          [5]string{"48;5;236", "48;5;22", "48;5;28", "48;5;34", "48;5;40"},
+	}
+	if ascii {
+		theme.Priority_Marks = [3]string{"~", "!", "!!"}
+		theme.Separator = "|"
+		theme.Bar = "#"
+		theme.Axis_Line = "-"
+		theme.Axis_Corner = "+"
+		theme.Heat_Shades = [5]string{".", ":", "+", "*", "#"}
+	}
+	return theme
+}
+
+var theme = default_theme(false)
+
+func (theme *Theme) Get_Priority_Mark(t *Task) string {
+	switch t.Priority {
+	case 1, 2:
+		return theme.Priority_Marks[t.Priority]
+	// Everything unusual is considered as active.
+	default:
+		return theme.Priority_Marks[SOMETIME_LATER_PRIORITY]
+	}
+}
+
+// Whether the output should be colored by the `color` setting.
+func detect_color(setting string, no_color string, tty bool) bool {
+	switch setting {
+	case "always":
+		return true
+	case "never":
+		return false
+	default:
+		return no_color == "" && tty
+	}
+}
+
+// Whether the terminal is unlikely to show the emoji, by the `ascii` setting.
+func detect_ascii(setting string, term_name string, locale string) bool {
+	switch setting {
+	case "true":
+		return true
+	case "false":
+		return false
+	default:
+		if term_name == "linux" || term_name == "dumb" {
+			return true
+		}
+		locale = strings.ToUpper(locale)
+		return locale != "" && !strings.Contains(locale, "UTF-8") && !strings.Contains(locale, "UTF8")
+	}
+}
+
+// Locale of the character classification, as resolved by the C library.
+func character_locale() string {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func parse_color(value string) (string, bool) {
+	if code, ok := COLORS[value]; ok {
+		return code, true
+	}
+	if sgr_code_regex.MatchString(value) {
+		return value, true
+	}
+	return "", false
+}
+
+// Build the theme out of the `theme` settings. Colors are dropped, unless
+// enabled. Returns the keys of the invalid colors, which are left by default.
+func build_theme(settings map[string]string, color bool, ascii bool) (*Theme, []string) {
+	theme := default_theme(ascii)
+	marks := map[string]*string{
+		"mark_active":    &theme.State_Marks[ACTIVE],
+		"mark_completed": &theme.State_Marks[COMPLETED],
+		"mark_rejected":  &theme.State_Marks[REJECTED],
+		"prio_later":     &theme.Priority_Marks[SOMETIME_LATER_PRIORITY],
+		"prio_week":      &theme.Priority_Marks[THIS_WEEK_PRIORITY],
+		"prio_today":     &theme.Priority_Marks[TODAY_PRIORITY],
+	}
+	colors := map[string]*string{
+		"color_active":         &theme.State_Colors[ACTIVE],
+		"color_completed":      &theme.State_Colors[COMPLETED],
+		"color_rejected":       &theme.State_Colors[REJECTED],
+		"color_prompt_project": &theme.Prompt_Project_Color,
+		"color_prompt_sign":    &theme.Prompt_Sign_Color,
+		"color_error":          &theme.Error_Color,
+	}
+	for i := range theme.Heat_Colors {
+		colors[fmt.Sprintf("color_heat_%d", i)] = &theme.Heat_Colors[i]
+	}
+
+	for key, mark := range marks {
+		if value, ok := settings[key]; ok {
+			*mark = value
+		}
+	}
+	invalid := []string{}
+	for key, target := range colors {
+		value, ok := settings[key]
+		if !ok {
+			continue
+		}
+		code, ok := parse_color(value)
+		if !ok {
+			invalid = append(invalid, key)
+			continue
+		}
+		*target = code
+	}
+	if !color {
+		for _, target := range colors {
+			*target = ""
+		}
+	}
+	slices.Sort(invalid)
+	return theme, invalid
+}
+
+// Load the theme from user.cfg, detecting the colors and ASCII mode by the
+// terminal. Errors are colored by the terminal of stderr.
+func load_theme() {
+	settings := map[string]string{}
+	for _, key := range bone.Config.Get_Keys("theme") {
+		settings[key] = bone.Config.Get_Raw_String("theme", key, "")
+	}
+	color_setting := settings["color"]
+	no_color := os.Getenv("NO_COLOR")
+	ascii := detect_ascii(settings["ascii"], os.Getenv("TERM"), character_locale())
+
+	var invalid []string
+	theme, invalid = build_theme(settings, detect_color(color_setting, no_color, is_terminal(os.Stdout)), ascii)
+	error_theme, _ := build_theme(settings, detect_color(color_setting, no_color, is_terminal(os.Stderr)), ascii)
+	bone.Error_Color = error_theme.Error_Color
+	if ascii {
+		term.Ellipsis = "..."
+	}
+	for _, key := range invalid {
+		bone.Log_Error("Unknown color '%s' of `theme.%s`, expected a name or SGR code.", settings[key], key)
+	}
+}
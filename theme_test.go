@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_build_theme_ok(t *testing.T) {
+	settings := map[string]string{
+		"mark_active":       "o",
+		"color_active":      "bright_cyan",
+		"color_completed":   "1;32",
+		"color_prompt_sign": "none",
+		"prio_today":        "!!!",
+	}
+	theme, invalid := build_theme(settings, true, false)
+	assert.Empty(t, invalid)
+	assert.Equal(t, "o", theme.State_Marks[ACTIVE])
+	assert.Equal(t, "96", theme.State_Colors[ACTIVE])
+	assert.Equal(t, "1;32", theme.State_Colors[COMPLETED])
+	assert.Equal(t, "", theme.Prompt_Sign_Color)
+	assert.Equal(t, "!!!", theme.Priority_Marks[TODAY_PRIORITY])
+	assert.Equal(t, "🟡", theme.Priority_Marks[THIS_WEEK_PRIORITY])
+
+	theme, _ = build_theme(settings, false, true)
+	assert.Equal(t, [3]string{"", "", ""}, theme.State_Colors)
+	assert.Equal(t, "", theme.Error_Color)
+	assert.Equal(t, "!", theme.Priority_Marks[THIS_WEEK_PRIORITY])
+	assert.Equal(t, "!!!", theme.Priority_Marks[TODAY_PRIORITY])
+	assert.Equal(t, "|", theme.Separator)
+}
+
+func Test_build_theme_error(t *testing.T) {
+	theme, invalid := build_theme(map[string]string{"color_error": "crimson", "color_active": "3;x"}, true, false)
+	assert.Equal(t, []string{"color_active", "color_error"}, invalid)
+	assert.Equal(t, "91", theme.Error_Color)
+	assert.Equal(t, "35", theme.State_Colors[ACTIVE])
+}
+
+func Test_detect_theme_ok(t *testing.T) {
+	assert.True(t, detect_color("", "", true))
+	assert.False(t, detect_color("", "1", true))
+	assert.False(t, detect_color("auto", "", false))
+	assert.True(t, detect_color("always", "1", false))
+	assert.False(t, detect_color("never", "", true))
+
+	assert.False(t, detect_ascii("", "xterm-256color", "en_US.UTF-8"))
+	assert.False(t, detect_ascii("", "xterm", ""))
+	assert.True(t, detect_ascii("", "linux", "en_US.UTF-8"))
+	assert.True(t, detect_ascii("auto", "xterm", "C"))
+	assert.True(t, detect_ascii("true", "xterm", "en_US.utf8"))
+	assert.False(t, detect_ascii("false", "dumb", "C"))
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"tasker/internal/bone"
+	"time"
+)
+
+// Conversion between tasks and the todo.txt format, see
+// https://github.com/todotxt/todo.txt
+//
+// Mapping:
+//   - `x` prefix: completed state
+//   - `(A)`, `(B)`: today and this week priorities, other letters are
+//     considered as sometime later
+//   - completion and creation dates
+//   - first `+project`: task project, the rest are kept in the title;
+//     underscores stand for the spaces of the project title, unless there is
+//     a project with the underscores
+//   - `@context`: task tag
+//   - `due:YYYY-MM-DD`: schedule
+//   - `pri:A`: priority of a completed task, as the format suggests
+//   - `state:rejected`: rejected state, which todo.txt has no notion of
+
+const TODOTXT_DATE_LAYOUT = "2006-01-02"
+
+var TODOTXT_DATE_REGEX = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+var TODOTXT_PRIORITY_REGEX = regexp.MustCompile(`^\([A-Z]\)$`)
+
+func todotxt_priority(letter string) int {
+	switch letter {
+	case "A":
+		return TODAY_PRIORITY
+	case "B":
+		return THIS_WEEK_PRIORITY
+	default:
+		return SOMETIME_LATER_PRIORITY
+	}
+}
+
+func todotxt_priority_letter(priority int) string {
+	switch priority {
+	case TODAY_PRIORITY:
+		return "A"
+	case THIS_WEEK_PRIORITY:
+		return "B"
+	default:
+		return ""
+	}
+}
+
+func parse_todotxt_date(s string) (int, error) {
+	date, er := time.ParseInLocation(TODOTXT_DATE_LAYOUT, s, time.Local)
+	if er != nil {
+		return 0, fmt.Errorf("invalid date '%s'", s)
+	}
+	return int(date.Unix()), nil
+}
+
+// Parse todo.txt file body. Blank lines are skipped. The first malformed line
+// fails the whole body.
+func parse_todotxt(body string) ([]*Import_Task, error) {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	tasks := []*Import_Task{}
+	for i, line := range strings.Split(body, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		t, er := parse_todotxt_line(line)
+		if er != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, er)
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
+
+func parse_todotxt_line(line string) (*Import_Task, error) {
+	fields := strings.Fields(line)
+	t := &Import_Task{Tags: []string{}}
+	i := 0
+
+	if fields[i] == "x" {
+		t.State = COMPLETED
+		i++
+	}
+	if i < len(fields) && TODOTXT_PRIORITY_REGEX.MatchString(fields[i]) {
+		t.Priority = todotxt_priority(fields[i][1:2])
+		i++
+	}
+
+	dates := []int{}
+	for i < len(fields) && len(dates) < 2 && TODOTXT_DATE_REGEX.MatchString(fields[i]) {
+		sec, er := parse_todotxt_date(fields[i])
+		if er != nil {
+			return nil, er
+		}
+		dates = append(dates, sec)
+		i++
+	}
+	completion_sec := 0
+	if t.State == COMPLETED && len(dates) > 0 {
+		completion_sec = dates[0]
+		dates = dates[1:]
+	}
+	if len(dates) > 1 {
+		return nil, fmt.Errorf("unexpected date before the description")
+	}
+	if len(dates) == 1 {
+		t.Created_Sec = dates[0]
+	}
+
+	words := []string{}
+	for _, w := range fields[i:] {
+		switch {
+		case len(w) > 1 && w[0] == '+' && t.Project == "":
+			t.Project = w[1:]
+			t.Project_Fallback = strings.ReplaceAll(w[1:], "_", " ")
+		case len(w) > 1 && w[0] == '@':
+			t.Tags = append(t.Tags, w[1:])
+		case strings.HasPrefix(w, "due:"):
+			schedule, ok := parse_schedule(strings.Replace(w[4:], "T", " ", 1))
+			if !ok {
+				return nil, fmt.Errorf("invalid due date '%s'", w[4:])
+			}
+			t.Schedule = bone.Atop(schedule.String())
+		case strings.HasPrefix(w, "pri:") && len(w) == 5:
+			t.Priority = todotxt_priority(w[4:])
+		case w == "state:rejected":
+			t.State = REJECTED
+		default:
+			words = append(words, w)
+		}
+	}
+	t.Title = strings.Join(words, " ")
+	if t.Title == "" {
+		return nil, fmt.Errorf("empty description")
+	}
+
+	switch t.State {
+	case COMPLETED:
+		t.Last_Completed_Sec = completion_sec
+	case REJECTED:
+		t.Last_Rejected_Sec = completion_sec
+	}
+	return t, nil
+}
+
+func format_todotxt_line(t *Export_Task) string {
+	parts := []string{}
+	priority := todotxt_priority_letter(t.Priority)
+
+	switch t.State {
+	case "completed":
+		parts = append(parts, "x")
+		if t.Last_Completed_Sec > 0 {
+			parts = append(parts, bone.Date_Sec(t.Last_Completed_Sec, TODOTXT_DATE_LAYOUT))
+		}
+	case "rejected":
+		parts = append(parts, "x")
+		if t.Last_Rejected_Sec > 0 {
+			parts = append(parts, bone.Date_Sec(t.Last_Rejected_Sec, TODOTXT_DATE_LAYOUT))
+		}
+	default:
+		if priority != "" {
+			parts = append(parts, "("+priority+")")
+		}
+	}
+	if t.Created_Sec > 0 {
+		parts = append(parts, bone.Date_Sec(t.Created_Sec, TODOTXT_DATE_LAYOUT))
+	}
+
+	parts = append(parts, t.Title)
+	if t.Project != "" {
+		parts = append(parts, "+"+strings.ReplaceAll(t.Project, " ", "_"))
+	}
+	for _, tag := range t.Tags {
+		parts = append(parts, "@"+tag)
+	}
+	if t.Schedule != nil {
+		parts = append(parts, "due:"+strings.ReplaceAll(*t.Schedule, " ", "T"))
+	}
+	if t.State != "active" && priority != "" {
+		parts = append(parts, "pri:"+priority)
+	}
+	if t.State == "rejected" {
+		parts = append(parts, "state:rejected")
+	}
+	return strings.Join(parts, " ")
+}
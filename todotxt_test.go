@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_todotxt_parse_ok(t *testing.T) {
+	task, er := parse_todotxt_line("x (A) 2026-05-20 2026-04-30 measure space +chapel @home @tools due:2026-05-30")
+	assert.Nil(t, er)
+	assert.Equal(t, COMPLETED, task.State)
+	assert.Equal(t, TODAY_PRIORITY, task.Priority)
+	assert.Equal(t, "measure space", task.Title)
+	assert.Equal(t, "chapel", task.Project)
+	assert.Equal(t, []string{"home", "tools"}, task.Tags)
+	assert.Equal(t, "2026-05-30", *task.Schedule)
+	assert.NotZero(t, task.Last_Completed_Sec)
+	assert.NotZero(t, task.Created_Sec)
+
+	task, er = parse_todotxt_line("paint fence +summer_house")
+	assert.Nil(t, er)
+	assert.Equal(t, "summer_house", task.Project)
+	assert.Equal(t, "summer house", task.Project_Fallback)
+}
+
+func Test_todotxt_import_ok(t *testing.T) {
+	tx := db.Begin()
+	_, er := insert_project(tx, "todotxt_snake_case")
+	assert.Nil(t, er)
+	assert.Nil(t, tx.Commit())
+
+	path := filepath.Join(t.TempDir(), "todo.txt")
+	body := "water plants +todotxt_snake_case\npaint fence +todotxt_summer_house\n"
+	assert.Nil(t, os.WriteFile(path, []byte(body), 0644))
+	assert.Equal(t, common.OK, process_input("import todotxt "+path))
+
+	tx = db.Begin()
+	defer tx.Rollback()
+	var project string
+	assert.Nil(t, tx.Get(&project, "SELECT p.title FROM task t JOIN project p ON p.id = t.project_id WHERE t.title = 'water plants'"))
+	assert.Equal(t, "todotxt_snake_case", project)
+	assert.Nil(t, tx.Get(&project, "SELECT p.title FROM task t JOIN project p ON p.id = t.project_id WHERE t.title = 'paint fence'"))
+	assert.Equal(t, "todotxt summer house", project)
+}
+
+func Test_todotxt_parse_error(t *testing.T) {
+	_, er := parse_todotxt("(B) call mom\n2026-13-40 broken date\n")
+	assert.EqualError(t, er, "line 2: invalid date '2026-13-40'")
+
+	_, er = parse_todotxt("x 2026-01-01 +work @home")
+	assert.EqualError(t, er, "line 1: empty description")
+
+	_, er = parse_todotxt("pay bills due:tomorrow")
+	assert.EqualError(t, er, "line 1: invalid due date 'tomorrow'")
+}
+
+func Test_todotxt_round_trip_ok(t *testing.T) {
+	lines := []string{
+		"(B) 2026-04-30 call mom +family @phone",
+		"x 2026-05-02 2026-04-30 pay bills +home due:2026-05 pri:A",
+		"x 2026-05-02 water plants +home state:rejected",
+		"2026-04-30 paint fence +summer_house @garden",
+	}
+	for _, line := range lines {
+		task, er := parse_todotxt_line(line)
+		assert.Nil(t, er)
+		export_task := &Export_Task{
+			Title:              task.Title,
+			State:              state_name(task.State),
+			Created_Sec:        task.Created_Sec,
+			Last_Completed_Sec: task.Last_Completed_Sec,
+			Last_Rejected_Sec:  task.Last_Rejected_Sec,
+			Priority:           task.Priority,
+			Schedule:           task.Schedule,
+			Project:            task.Project,
+			Tags:               task.Tags,
+		}
+		assert.Equal(t, line, format_todotxt_line(export_task))
+	}
+}
@@ -0,0 +1,459 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"tasker/internal/line"
+	"tasker/internal/term"
+)
+
+// Full-screen terminal UI, started by `tasker --tui`. Projects are listed on
+// the left, tasks of the selected project on the right. Changes are made by
+// the same task operations as the commands use.
+//
+// Keys:
+//   - Tab: switch between the project and the task panes
+//   - Left/Right, h/l: go to the project or to the task pane
+//   - Up/Down, k/j: select
+//   - Enter: open the selected project
+//   - c or +: complete the task
+//   - r or -: reject the task
+//   - a: add a task, or a project in the project pane
+//   - e: edit the task title
+//   - m: move the task to another project
+//   - d: delete the task, after confirmation
+//   - v: cycle shown tasks: active, completed, rejected, all
+//   - /: filter tasks by title as you type, Enter to keep the filter, Escape
+//     to clear it
+//   - q, Ctrl-C: quit
+
+const (
+	TUI_PROJECTS = iota
+	TUI_TASKS
+)
+
+// Shown task states, cycled in this order. Negative shows all.
+var TUI_STATES = []int{ACTIVE, COMPLETED, REJECTED, -1}
+
+const TUI_HINT = "a:add e:edit c:complete r:reject m:move d:delete v:view /:filter q:quit"
+
+type Tui struct {
+	editor *line.Editor
+	out    *bufio.Writer
+
+	projects []*Project
+	// Tasks of the selected project, after filtering.
+	tasks []*Task
+
+	project int
+	task    int
+	focus   int
+	state   int
+
+	filter    string
+	filtering bool
+	status    string
+}
+
+func new_tui(in *os.File, out io.Writer) *Tui {
+	buffered := bufio.NewWriter(out)
+	t := &Tui{
+		editor: line.New_Editor(in, out, ""),
+		out:    buffered,
+		focus:  TUI_TASKS,
+	}
+	for i, p := range t.load_projects() {
+		if p.Id == current_project_id {
+			t.project = i
+		}
+	}
+	t.load()
+	return t
+}
+
+func run_tui() int {
+	if !is_terminal(os.Stdin) || !is_terminal(os.Stdout) {
+		bone.Log_Error("TUI needs an interactive terminal.")
+		return common.INPUT_ERROR
+	}
+	state, er := term.Make_Raw(os.Stdin)
+	if er != nil {
+		bone.Log_Error("Cannot switch the terminal to raw mode: %s", er)
+		return common.ERROR
+	}
+	defer term.Restore(os.Stdin, state)
+
+	// Alternate screen keeps the shell output intact after exit.
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	t := new_tui(os.Stdin, os.Stdout)
+	for {
+		t.render()
+		key, er := t.editor.Read_Key()
+		if er != nil {
+			return common.OK
+		}
+		if t.handle_key(key) {
+			return common.OK
+		}
+	}
+}
+
+func (t *Tui) load_projects() []*Project {
+	tx := db.Begin()
+	defer tx.Rollback()
+	t.projects = []*Project{}
+	er := tx.Select(&t.projects, "SELECT * FROM project ORDER BY id ASC")
+	if er != nil {
+		t.status = fmt.Sprintf("Cannot load projects: %s", er)
+	}
+	return t.projects
+}
+
+// Reload projects and tasks, keeping the selection within bounds.
+func (t *Tui) load() {
+	t.load_projects()
+	t.project = clamp(t.project, len(t.projects))
+	t.tasks = []*Task{}
+	if len(t.projects) == 0 {
+		return
+	}
+
+	tx := db.Begin()
+	defer tx.Rollback()
+	query := "SELECT * FROM task WHERE project_id = $1"
+	args := []any{t.projects[t.project].Id}
+	if state := TUI_STATES[t.state]; state >= 0 {
+		query += " AND state = $2"
+		args = append(args, state)
+	}
+	query += " ORDER BY state ASC, completion_priority DESC, created_sec ASC"
+	tasks := []*Task{}
+	er := tx.Select(&tasks, query, args...)
+	if er != nil {
+		t.status = fmt.Sprintf("Cannot load tasks: %s", er)
+	}
+
+	filter := strings.ToLower(t.filter)
+	for _, task := range tasks {
+		if strings.Contains(strings.ToLower(task.Title), filter) {
+			t.tasks = append(t.tasks, task)
+		}
+	}
+	t.task = clamp(t.task, len(t.tasks))
+}
+
+func clamp(i int, length int) int {
+	if i >= length {
+		i = length - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
+
+func (t *Tui) selected_task() *Task {
+	if len(t.tasks) == 0 {
+		return nil
+	}
+	return t.tasks[t.task]
+}
+
+// Apply the change in its own transaction and reload.
+func (t *Tui) mutate(change func(tx *db.Tx) error, message string) {
+	er := apply_change(change)
+	if er != nil {
+		t.status = fmt.Sprintf("Error: %s", er)
+	} else {
+		t.status = message
+	}
+	t.load()
+}
+
+func apply_change(change func(tx *db.Tx) error) error {
+	tx := db.Begin()
+	defer tx.Rollback()
+	er := change(tx)
+	if er != nil {
+		return er
+	}
+	return tx.Commit()
+}
+
+// Returns true to quit.
+func (t *Tui) handle_key(key string) bool {
+	if t.filtering {
+		t.handle_filter_key(key)
+		return false
+	}
+	t.status = ""
+
+	switch {
+	case key == "q", key == line.KEY_CTRL_C:
+		return true
+	case key == line.KEY_TAB:
+		t.focus = 1 - t.focus
+	case key == "h", slices.Contains(line.KEYS_LEFT, key):
+		t.focus = TUI_PROJECTS
+	case key == "l", slices.Contains(line.KEYS_RIGHT, key), slices.Contains(line.KEYS_ENTER, key):
+		t.focus = TUI_TASKS
+	case key == "k", slices.Contains(line.KEYS_UP, key):
+		t.move_selection(-1)
+	case key == "j", slices.Contains(line.KEYS_DOWN, key):
+		t.move_selection(1)
+	}
+
+	switch key {
+	case "v":
+		t.state = (t.state + 1) % len(TUI_STATES)
+		t.load()
+	case "/":
+		t.filtering = true
+	case line.KEY_ESCAPE:
+		t.filter = ""
+		t.load()
+	case "a":
+		t.add()
+	case "c", "+":
+		t.set_state(COMPLETED, "Completed task.")
+	case "r", "-":
+		t.set_state(REJECTED, "Rejected task.")
+	case "e":
+		t.edit()
+	case "m":
+		t.move()
+	case "d":
+		t.delete()
+	}
+	return false
+}
+
+func (t *Tui) handle_filter_key(key string) {
+	switch {
+	case slices.Contains(line.KEYS_ENTER, key):
+		t.filtering = false
+	case key == line.KEY_ESCAPE, key == line.KEY_CTRL_C:
+		t.filtering = false
+		t.filter = ""
+	case slices.Contains(line.KEYS_BACKSPACE, key):
+		r := []rune(t.filter)
+		if len(r) > 0 {
+			t.filter = string(r[:len(r)-1])
+		}
+	default:
+		if term.Width(key) > 0 && !strings.HasPrefix(key, "\x1b") {
+			t.filter += key
+		}
+	}
+	t.load()
+}
+
+func (t *Tui) move_selection(delta int) {
+	if t.focus == TUI_PROJECTS {
+		t.project = clamp(t.project+delta, len(t.projects))
+		t.task = 0
+	} else {
+		t.task = clamp(t.task+delta, len(t.tasks))
+	}
+	t.load()
+}
+
+// Ask for the text at the bottom line. Empty answer cancels.
+func (t *Tui) ask(prompt string, initial string) string {
+	_, rows := tui_size()
+	fmt.Fprintf(t.out, "\x1b[%d;1H\x1b[K\x1b[?25h", rows)
+	t.out.Flush()
+	answer, er := t.editor.Edit(prompt, initial)
+	fmt.Fprint(t.out, "\x1b[?25l")
+	if er != nil {
+		return ""
+	}
+	return strings.TrimSpace(answer)
+}
+
+func (t *Tui) add() {
+	if t.focus == TUI_PROJECTS {
+		title := t.ask("New project: ", "")
+		if title == "" {
+			return
+		}
+		t.mutate(func(tx *db.Tx) error {
+			_, er := insert_project(tx, title)
+			return er
+		}, "Project created.")
+		return
+	}
+	if len(t.projects) == 0 {
+		return
+	}
+	title := t.ask("New task: ", "")
+	if title == "" {
+		return
+	}
+	project_id := t.projects[t.project].Id
+	t.mutate(func(tx *db.Tx) error {
+		_, er := insert_task(tx, title, project_id)
+		return er
+	}, "Task created.")
+}
+
+func (t *Tui) set_state(state int, message string) {
+	task := t.selected_task()
+	if task == nil {
+		return
+	}
+	t.mutate(func(tx *db.Tx) error {
+		return set_task_state(tx, task.Id, state)
+	}, message)
+}
+
+func (t *Tui) edit() {
+	task := t.selected_task()
+	if task == nil {
+		return
+	}
+	title := t.ask("Title: ", task.Title)
+	if title == "" || title == task.Title {
+		return
+	}
+	t.mutate(func(tx *db.Tx) error {
+		return set_task_title(tx, task.Id, title)
+	}, "Task updated.")
+}
+
+func (t *Tui) move() {
+	task := t.selected_task()
+	if task == nil {
+		return
+	}
+	t.editor.Complete = func(input string) (int, []string) {
+		return 0, filter_prefix(project_names(), input)
+	}
+	project_name := t.ask("Move to project: ", "")
+	t.editor.Complete = nil
+	if project_name == "" {
+		return
+	}
+	t.mutate(func(tx *db.Tx) error {
+		project, er := get_project(tx, project_name)
+		if er != nil {
+			return fmt.Errorf("no project '%s'", project_name)
+		}
+		return move_task(tx, task.Id, project.Id)
+	}, fmt.Sprintf("Moved task to project '%s'.", project_name))
+}
+
+func (t *Tui) delete() {
+	task := t.selected_task()
+	if task == nil {
+		return
+	}
+	t.status = fmt.Sprintf("Delete '%s'? [y/N]", task.Title)
+	t.render()
+	key, er := t.editor.Read_Key()
+	if er != nil || (key != "y" && key != "Y") {
+		t.status = ""
+		return
+	}
+	t.mutate(func(tx *db.Tx) error {
+		return delete_task(tx, task.Id)
+	}, "Task deleted.")
+}
+
+func tui_size() (int, int) {
+	columns, rows, er := term.Size(os.Stdout)
+	if er != nil || columns <= 0 || rows <= 0 {
+		return term.DEFAULT_COLUMNS, term.DEFAULT_ROWS
+	}
+	return columns, rows
+}
+
+// Scroll offset, which keeps the selected row visible.
+func scroll_offset(selected int, height int) int {
+	if selected < height {
+		return 0
+	}
+	return selected - height + 1
+}
+
+func (t *Tui) project_line(i int) string {
+	if i >= len(t.projects) {
+		return ""
+	}
+	text := " " + t.projects[i].Title
+	if i == t.project {
+		text = ">" + t.projects[i].Title
+		if t.focus == TUI_PROJECTS {
+			return "\x1b[7m" + text
+		}
+	}
+	return text
+}
+
+func (t *Tui) task_line(i int) string {
+	if i >= len(t.tasks) {
+		if i == 0 {
+			return " No tasks"
+		}
+		return ""
+	}
+	task := t.tasks[i]
+	schedule := ""
+	if task.Schedule != nil {
+		schedule = fmt.Sprintf(" [%s]", *task.Schedule)
+	}
+	if i == t.task && t.focus == TUI_TASKS {
+		// Colored marks would reset the highlight.
+		return fmt.Sprintf("\x1b[7m %s %s %s%s", task.Get_State_Mark(), task.Get_Priority_Mark(), task.Title, schedule)
+	}
+	return fmt.Sprintf(" %s %s %s%s", task.Get_Completion_Mark(), task.Get_Priority_Mark(), task.Title, schedule)
+}
+
+func (t *Tui) render() {
+	columns, rows := tui_size()
+	left_width := min(24, columns/3)
+	right_width := columns - left_width - 1
+	height := rows - 2
+
+	project_name := ""
+	if len(t.projects) > 0 {
+		project_name = t.projects[t.project].Title
+	}
+	view := "all"
+	if state := TUI_STATES[t.state]; state >= 0 {
+		view = state_name(state)
+	}
+	header := fmt.Sprintf(" tasker: %s [%s]", project_name, view)
+	if t.filter != "" || t.filtering {
+		header += fmt.Sprintf(" /%s", t.filter)
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	b.WriteString("\x1b[1m" + term.Pad(header, columns) + "\x1b[0m\r\n")
+	project_offset := scroll_offset(t.project, height)
+	task_offset := scroll_offset(t.task, height)
+	for row := 0; row < height; row++ {
+		left := term.Pad(t.project_line(project_offset+row), left_width)
+		right := term.Pad(t.task_line(task_offset+row), right_width)
+		b.WriteString(left + "\x1b[0m" + theme.Separator + right + "\x1b[0m\r\n")
+	}
+	footer := t.status
+	if t.filtering {
+		footer = "Filter: " + t.filter
+	} else if footer == "" {
+		footer = TUI_HINT
+	}
+	b.WriteString(term.Pad(footer, columns))
+	t.out.WriteString(b.String())
+	t.out.Flush()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"tasker/internal/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TUI on its own project, with keys for prompts read from the pipe.
+func new_test_tui(t *testing.T, project string) (*Tui, *os.File, *bytes.Buffer) {
+	tx := db.Begin()
+	_, er := insert_project(tx, project)
+	assert.Nil(t, er)
+	assert.Nil(t, tx.Commit())
+
+	r, w, er := os.Pipe()
+	assert.Nil(t, er)
+	t.Cleanup(func() {
+		r.Close()
+		w.Close()
+	})
+	var output bytes.Buffer
+	ui := new_tui(r, &output)
+	for i, p := range ui.projects {
+		if p.Title == project {
+			ui.project = i
+		}
+	}
+	ui.load()
+	return ui, w, &output
+}
+
+func tui_titles(ui *Tui) []string {
+	titles := []string{}
+	for _, task := range ui.tasks {
+		titles = append(titles, task.Title)
+	}
+	return titles
+}
+
+func Test_tui_ok(t *testing.T) {
+	ui, keys, output := new_test_tui(t, "tui")
+
+	keys.WriteString("First\r")
+	ui.handle_key("a")
+	keys.WriteString("Second\r")
+	ui.handle_key("a")
+	assert.Equal(t, []string{"First", "Second"}, tui_titles(ui))
+
+	ui.handle_key("j")
+	keys.WriteString("\x15Renamed\r")
+	ui.handle_key("e")
+	assert.Equal(t, []string{"First", "Renamed"}, tui_titles(ui))
+
+	ui.handle_key("/")
+	ui.handle_key("f")
+	ui.handle_key("\r")
+	assert.Equal(t, []string{"First"}, tui_titles(ui))
+	ui.handle_key("c")
+	assert.Empty(t, tui_titles(ui))
+	ui.handle_key("\x1b")
+
+	keys.WriteString("n")
+	ui.handle_key("d")
+	assert.Equal(t, []string{"Renamed"}, tui_titles(ui))
+	keys.WriteString("y")
+	ui.handle_key("d")
+	assert.Empty(t, tui_titles(ui))
+	assert.Equal(t, "Task deleted.", ui.status)
+
+	ui.handle_key("v")
+	assert.Equal(t, []string{"First"}, tui_titles(ui))
+
+	ui.render()
+	assert.Contains(t, output.String(), "tasker: tui [completed]")
+	assert.True(t, ui.handle_key("q"))
+}
+
+func Test_tui_move_error(t *testing.T) {
+	ui, keys, _ := new_test_tui(t, "tui_move")
+	keys.WriteString("Task\r")
+	ui.handle_key("a")
+
+	keys.WriteString("nosuchproject\r")
+	ui.handle_key("m")
+	assert.Equal(t, "Error: no project 'nosuchproject'", ui.status)
+	assert.Len(t, ui.tasks, 1)
+
+	tx := db.Begin()
+	_, er := insert_project(tx, "tui_move_to")
+	assert.Nil(t, er)
+	assert.Nil(t, tx.Commit())
+	keys.WriteString("tui_move_to\r")
+	ui.handle_key("m")
+	assert.Empty(t, ui.tasks)
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"tasker/internal/bone"
+	"tasker/internal/common"
+	"tasker/internal/db"
+)
+
+// Views are `show` argument sets saved under a name, e.g. `v done -set -c
+// -ocompleted -reverse -scompleted`. A view is either global, or visible only
+// from the project it was saved in, and the project view shadows the global
+// one of the same name. Views are kept in the database, along with the
+// projects they span.
+
+type View struct {
+	Id         int    `db:"id"`
+	Name       string `db:"name"`
+	Args       string `db:"args"`
+	Project_Id *int   `db:"project_id"`
+}
+
+// Find the view visible from the current project.
+func get_view(tx *db.Tx, name string) (*View, error) {
+	var view View
+	er := tx.Get(
+		&view,
+		`SELECT * FROM view WHERE name = $1 AND (project_id = $2 OR project_id IS NULL)
+		ORDER BY project_id IS NULL ASC LIMIT 1`,
+		name,
+		current_project_id,
+	)
+	if er != nil {
+		return nil, er
+	}
+	return &view, nil
+}
+
+// Ids of the projects the view spans, nil if it shows the current project.
+func get_view_project_ids(tx *db.Tx, view_id int) ([]int, error) {
+	ids := []int{}
+	er := tx.Select(&ids, "SELECT project_id FROM view_project WHERE view_id = $1 ORDER BY project_id ASC", view_id)
+	if er != nil || len(ids) == 0 {
+		return nil, er
+	}
+	return ids, nil
+}
+
+// Work with the saved views.
+//
+// Args:
+//   - 1: view name, list the views visible from the current project if
+//     omitted
+//   - `-set ARGS...`: save the rest of the arguments as the view, replacing
+//     the existing one
+//   - `-global`: with `-set`, save the view visible from every project
+//   - `-projects P1,P2`: with `-set`, show the tasks of these projects instead
+//     of the current one
+//   - `-d`: delete the view
+//
+// Arguments after the name of the view being run are appended to the saved
+// ones.
+func view(ctx *Command_Context) int {
+	if len(ctx.Args) == 0 {
+		return list_views(ctx)
+	}
+	name := ctx.Args[0]
+	if strings.HasPrefix(name, "-") {
+		bone.Log_Error("View name cannot start with '-'.")
+		return common.INPUT_ERROR
+	}
+	has_set, set_index := ctx.Has_Arg_Index("-set")
+	if has_set {
+		return set_view(ctx, name, ctx.Args[1:set_index], ctx.Args[set_index+1:])
+	}
+	if ctx.Has_Arg("-d") {
+		return delete_view(ctx, name)
+	}
+	return run_view(ctx, name, ctx.Args[1:])
+}
+
+func list_views(ctx *Command_Context) int {
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	views := []*View{}
+	er := tx.Select(
+		&views,
+		"SELECT * FROM view WHERE project_id = $1 OR project_id IS NULL ORDER BY name ASC, project_id IS NULL ASC",
+		current_project_id,
+	)
+	if er != nil {
+		bone.Log_Error("During view selection, an error occured: %s", er)
+		return common.SELECT_ERROR
+	}
+	project_titles, er := get_project_titles(tx)
+	if er != nil {
+		bone.Log_Error("During project selection, an error occured: %s", er)
+		return common.SELECT_ERROR
+	}
+
+	if len(views) == 0 {
+		emit_message("No views")
+		return common.OK
+	}
+	shown := map[string]bool{}
+	for _, v := range views {
+		scope := "global"
+		if v.Project_Id != nil {
+			scope = project_titles[*v.Project_Id]
+		}
+		project_ids, er := get_view_project_ids(tx, v.Id)
+		if er != nil {
+			bone.Log_Error("During view selection, an error occured: %s", er)
+			return common.SELECT_ERROR
+		}
+		line := fmt.Sprintf("%-12s (%s) %s", v.Name, scope, v.Args)
+		if project_ids != nil {
+			titles := []string{}
+			for _, id := range project_ids {
+				titles = append(titles, project_titles[id])
+			}
+			line += fmt.Sprintf(" @ %s", strings.Join(titles, ","))
+		}
+		if shown[v.Name] {
+			line += " (shadowed)"
+		}
+		shown[v.Name] = true
+		emit_message("%s", strings.TrimRight(line, " "))
+	}
+	return common.OK
+}
+
+func run_view(ctx *Command_Context, name string, extra_args []string) int {
+	// The view is read in its own transaction, since showing begins another
+	// one.
+	view, project_ids, e := read_view(ctx, name)
+	if e != common.OK {
+		return e
+	}
+	if project_ids == nil {
+		project_ids = []int{current_project_id}
+	}
+	view_ctx := &Command_Context{
+		Raw_Input:    ctx.Raw_Input,
+		Command_Name: "s",
+		Args:         append(strings.Fields(view.Args), extra_args...),
+		Tx:           ctx.Tx,
+	}
+	return show_tasks(view_ctx, project_ids)
+}
+
+func read_view(ctx *Command_Context, name string) (*View, []int, int) {
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	view, er := get_view(tx, name)
+	if er != nil {
+		bone.Log_Error("No view '%s'.", name)
+		return nil, nil, common.NO_SUCH_VIEW
+	}
+	project_ids, er := get_view_project_ids(tx, view.Id)
+	if er != nil {
+		bone.Log_Error("During view selection, an error occured: %s", er)
+		return nil, nil, common.SELECT_ERROR
+	}
+	return view, project_ids, common.OK
+}
+
+func set_view(ctx *Command_Context, name string, options []string, args []string) int {
+	_, er := parse_task_query(strings.Join(args, " "))
+	if er != nil {
+		log_query_error(args, er)
+		return common.INPUT_ERROR
+	}
+
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	var project_id *int
+	if !slices.Contains(options, "-global") {
+		project_id = &current_project_id
+	}
+	project_ids := []int{}
+	if i := slices.Index(options, "-projects"); i >= 0 {
+		if i+1 >= len(options) {
+			bone.Log_Error("Expected project names after `-projects`.")
+			return common.INPUT_ERROR
+		}
+		for _, project_name := range strings.Split(options[i+1], ",") {
+			project, er := get_project(tx, project_name)
+			if er != nil {
+				bone.Log_Error("Cannot find project '%s'.", project_name)
+				return common.NO_SUCH_PROJECT
+			}
+			if !slices.Contains(project_ids, project.Id) {
+				project_ids = append(project_ids, project.Id)
+			}
+		}
+	}
+
+	if project_id == nil {
+		_, er = tx.Exec("DELETE FROM view WHERE name = $1 AND project_id IS NULL", name)
+	} else {
+		_, er = tx.Exec("DELETE FROM view WHERE name = $1 AND project_id = $2", name, *project_id)
+	}
+	if er != nil {
+		bone.Log_Error("During view replacement, an error occured: %s", er)
+		return common.DELETE_ERROR
+	}
+	result, er := tx.Exec(
+		"INSERT INTO view (name, args, project_id) VALUES ($1, $2, $3)",
+		name,
+		strings.Join(args, " "),
+		project_id,
+	)
+	if er != nil {
+		bone.Log_Error("During view insertion, an error occured: %s", er)
+		return common.INSERT_ERROR
+	}
+	view_id, _ := result.LastInsertId()
+	for _, id := range project_ids {
+		_, er = tx.Exec("INSERT INTO view_project (view_id, project_id) VALUES ($1, $2)", view_id, id)
+		if er != nil {
+			bone.Log_Error("During view insertion, an error occured: %s", er)
+			return common.INSERT_ERROR
+		}
+	}
+
+	er = tx.Commit()
+	if er != nil {
+		return common.COMMIT_ERROR
+	}
+	emit_message("Saved view '%s'.", name)
+	return common.OK
+}
+
+// Delete the view visible from the current project, so the project view goes
+// first.
+func delete_view(ctx *Command_Context, name string) int {
+	tx := ctx.Begin()
+	defer tx.Rollback()
+
+	view, er := get_view(tx, name)
+	if er != nil {
+		bone.Log_Error("No view '%s'.", name)
+		return common.NO_SUCH_VIEW
+	}
+	_, er = tx.Exec("DELETE FROM view WHERE id = $1", view.Id)
+	if er != nil {
+		bone.Log_Error("During view deletion, an error occured: %s", er)
+		return common.DELETE_ERROR
+	}
+	er = tx.Commit()
+	if er != nil {
+		return common.COMMIT_ERROR
+	}
+	emit_message("Deleted view '%s'.", name)
+	return common.OK
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"tasker/internal/common"
+	"tasker/internal/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_view_ok(t *testing.T) {
+	home_id, output := with_project_output(t, "view_home", "plain")
+	tx := db.Begin()
+	other_id, er := insert_project(tx, "view_other")
+	assert.Nil(t, er)
+	_, er = insert_task(tx, "Home task", home_id)
+	assert.Nil(t, er)
+	_, er = insert_task(tx, "Other task", other_id)
+	assert.Nil(t, er)
+	assert.Nil(t, tx.Commit())
+
+	assert.Equal(t, common.OK, process_input("v both -global -projects view_home,view_other -set"))
+	assert.Equal(t, common.OK, process_input("v home -set -reverse"))
+	output.Reset()
+	assert.Equal(t, common.OK, process_input("v both"))
+	assert.Equal(t, "|1| . [view_home]  Home task\n|2| . [view_other] Other task\n", output.String())
+
+	output.Reset()
+	assert.Equal(t, common.OK, process_input("v home"))
+	assert.Equal(t, "|1| . Home task\n", output.String())
+
+	// Project view shadows the global one, and is visible only from its
+	// project.
+	assert.Equal(t, common.OK, process_input("v both -set -c"))
+	output.Reset()
+	assert.Equal(t, common.OK, process_input("v both"))
+	assert.Equal(t, "No tasks\n", output.String())
+	current_project_id = other_id
+	assert.Equal(t, common.NO_SUCH_VIEW, process_input("v home"))
+	output.Reset()
+	assert.Equal(t, common.OK, process_input("v both"))
+	assert.Equal(t, "|1| . [view_home]  Home task\n|2| . [view_other] Other task\n", output.String())
+	current_project_id = home_id
+
+	assert.Equal(t, common.OK, process_input("v both -d"))
+	output.Reset()
+	assert.Equal(t, common.OK, process_input("v both"))
+	assert.Contains(t, output.String(), "[view_other] Other task")
+}
+
+func Test_view_error(t *testing.T) {
+	assert.Equal(t, common.NO_SUCH_VIEW, process_input("v nosuchview"))
+	assert.Equal(t, common.NO_SUCH_VIEW, process_input("v nosuchview -d"))
+	assert.Equal(t, common.NO_SUCH_PROJECT, process_input("v x -projects nosuchproject -set -reverse"))
+	assert.Equal(t, common.INPUT_ERROR, process_input("v -set -reverse"))
+}